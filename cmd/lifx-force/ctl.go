@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/control"
+)
+
+const ctlUsage = `Usage: lifx-force ctl <command> [argument]
+
+Commands:
+  devices            list the discovered devices
+  bindings           list the configured bindings
+  trigger <name>     run the binding with the given name
+  pause              stop actioning bindings
+  resume             resume actioning bindings
+  reload             reload the bindings and log level from the config file
+  log-level <level>  set the log level (debug, info, warn, error)
+`
+
+// controlSocket returns the path of the control socket set in the config,
+// or its default in the lifx-force directory.
+func controlSocket(cfg *config.Config, homeDir string) string {
+	if cfg.Control.Socket != "" {
+		return cfg.Control.Socket
+	}
+	return filepath.Join(homeDir, ".lifx-force", "control.sock")
+}
+
+// runCtl sends a command to a running lifx-force through its control socket,
+// printing the response and returning the exit code.
+func runCtl(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, ctlUsage)
+		return 2
+	}
+
+	var req control.Request
+	switch cmd := args[0]; {
+	case len(args) == 1 && (cmd == "devices" || cmd == "bindings" || cmd == "pause" || cmd == "resume" || cmd == "reload"):
+		req = control.Request{Command: cmd}
+	case len(args) == 2 && cmd == "trigger":
+		req = control.Request{Command: control.CommandTrigger, Binding: args[1]}
+	case len(args) == 2 && cmd == "log-level":
+		req = control.Request{Command: control.CommandLogLevel, Level: args[1]}
+	default:
+		fmt.Fprint(os.Stderr, ctlUsage)
+		return 2
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load homedir:", err)
+		return 1
+	}
+	// The config is only read for the socket path, so that ctl never writes
+	// the config file nor fails on a config the running instance rejected.
+	cfg, err := config.ReadConfig(filepath.Join(homeDir, ".lifx-force", "config.toml"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Failed to read config file, using the default socket:", err)
+		}
+		cfg = &config.Config{}
+	}
+
+	resp, err := control.Send(controlSocket(cfg, homeDir), req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintln(os.Stderr, "Error:", resp.Error)
+		return 1
+	}
+	if len(resp.Result) == 0 {
+		fmt.Println("ok")
+		return 0
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, resp.Result, "", "  "); err != nil {
+		out.Write(resp.Result)
+	}
+	fmt.Println(out.String())
+	return 0
+}
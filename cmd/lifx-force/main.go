@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -13,10 +14,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/alessio-palumbo/lifx-force/internal/api"
 	"github.com/alessio-palumbo/lifx-force/internal/config"
 	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/control"
 	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/mqtt"
+	"github.com/alessio-palumbo/lifx-force/internal/osc"
 	"github.com/alessio-palumbo/lifx-force/internal/runtime"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
 	"github.com/alessio-palumbo/lifx-force/internal/version"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/controller"
 
@@ -28,6 +34,9 @@ func main() {
 		version.Print()
 		os.Exit(0)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtl(os.Args[2:]))
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -36,13 +45,17 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to load homedir:", err)
 	}
-	cfg, err := config.LoadConfig(filepath.Join(homeDir, ".lifx-force", "config.toml"))
+	cfgPath := filepath.Join(homeDir, ".lifx-force", "config.toml")
+	cfg, err := config.LoadConfig(cfgPath)
 	if err != nil {
 		log.Fatal("Failed to load config file:", err)
 	}
 
+	// Keep a reference to the level setter as the package is shadowed by the logger.
+	setLogLevel := logger.SetLevel
 	logger := logger.SetupLogger(cfg)
 	logger.Info("Starting lifx-force")
+	logConfigWarnings(cfg, logger)
 
 	exePath, err := runtime.EnsureFingertrackInstalled(logger)
 	if err != nil {
@@ -57,13 +70,17 @@ func main() {
 	}
 	defer ctrl.Close()
 
-	// Allow discovery to occur so that Labels and Groups are available to consumer.
-	// TODO Add some logic to periodically refresh the list of devices inside the consumer.
+	// Allow discovery to occur so that devices are available to the first events.
+	// Bindings resolve their targets from the controller's device list when actioned.
 	time.Sleep(2 * time.Second)
 
 	cmd := exec.CommandContext(ctx, exePath, runtime.ArgsFromConfig(cfg)...)
 	cmd.Cancel = func() error {
 		logger.Info("Process cancelled: terminating fingertrack")
+		if cfg.Tracking.SuspendWhenPaused {
+			// A suspended process would not handle the signal.
+			suspendProcess(cmd.Process, false)
+		}
 		return cmd.Process.Signal(syscall.SIGTERM)
 	}
 	stdout, err := cmd.StdoutPipe()
@@ -77,9 +94,96 @@ func main() {
 	if err := cmd.Start(); err != nil {
 		log.Fatal("Failed to start fingertrack:", err)
 	}
+	// done is closed once fingertrack has exited, after its output is read.
+	done := make(chan struct{})
 
 	logger.Info("Starting consumer")
 	c := consumer.New(cfg, ctrl, logger)
+	defer c.Close()
+	go c.Run(ctx)
+
+	// The hub fans out the events and actions of the consumer to the integrations.
+	hub := stream.NewHub(stream.DefaultBufferSize, logger)
+	c.SetRecorder(hub)
+
+	var occupancy *consumer.Occupancy
+	if cfg.Occupancy.Enabled {
+		logger.Info("Starting occupancy monitor")
+		occupancy = consumer.NewOccupancy(cfg, ctrl, logger)
+		go occupancy.Run(ctx)
+	}
+
+	c.SetPauseHandler(func(paused bool) {
+		if occupancy != nil {
+			occupancy.SetPaused(paused)
+		}
+		if cfg.Tracking.SuspendWhenPaused {
+			if err := suspendProcess(cmd.Process, paused); err != nil {
+				logger.Warn("Failed to suspend fingertrack", slog.Any("error", err))
+			}
+		}
+	})
+	notifyTogglePause(ctx, c)
+
+	if cfg.API.Listen != "" {
+		running := func() bool {
+			select {
+			case <-done:
+				return false
+			default:
+				return true
+			}
+		}
+		srv := api.New(cfg, ctrl, c, hub, running, logger)
+		go func() {
+			if err := srv.Run(ctx); err != nil {
+				logger.Error(fmt.Sprintf("API server error: %v", err))
+			}
+		}()
+	}
+
+	var mqttClient *mqtt.Client
+	if cfg.MQTT.Broker != "" {
+		logger.Info("Starting MQTT client")
+		client, err := mqtt.New(cfg, c, hub, logger)
+		if err != nil {
+			log.Fatal("Failed to initialize MQTT client:", err)
+		}
+		go client.Run(ctx)
+		mqttClient = client
+	}
+
+	if cfg.OSC.Host != "" {
+		logger.Info("Starting OSC sender")
+		sender, err := osc.New(cfg, hub, logger)
+		if err != nil {
+			log.Fatal("Failed to initialize OSC sender:", err)
+		}
+		go sender.Run(ctx)
+	}
+
+	if cfg.Control.Enabled {
+		// Only the bindings, with their zones, and log level are reloaded,
+		// other settings require a restart.
+		reload := func() error {
+			newCfg, err := config.LoadConfig(cfgPath)
+			if err != nil {
+				return err
+			}
+			logConfigWarnings(newCfg, logger)
+			c.SetBindings(newCfg.Bindings, newCfg.Zones)
+			if mqttClient != nil {
+				mqttClient.SetBindings(newCfg.Bindings)
+			}
+			return setLogLevel(newCfg.Logging.Level)
+		}
+		srv := control.New(controlSocket(cfg, homeDir), ctrl, c, reload, setLogLevel, logger)
+		go func() {
+			if err := srv.Run(ctx); err != nil {
+				logger.Error(fmt.Sprintf("Control socket error: %v", err))
+			}
+		}()
+	}
 
 	go func() {
 		scanner := bufio.NewScanner(stdout)
@@ -89,11 +193,18 @@ func main() {
 				continue
 			}
 
+			if occupancy != nil {
+				occupancy.Observe(&event)
+			}
 			c.HandleEvent(&event)
 		}
 		if err := scanner.Err(); err != nil {
 			logger.Error(fmt.Sprintf("Scanner error: %v", err))
 		}
+		// Wait closes the stdout pipe, so it is only called once all the
+		// output has been read.
+		cmd.Wait()
+		close(done)
 	}()
 
 	<-ctx.Done()
@@ -101,12 +212,6 @@ func main() {
 
 	// Graceful stop
 	stop()
-	done := make(chan struct{})
-	go func() {
-		cmd.Wait()
-		close(done)
-	}()
-
 	select {
 	case <-done:
 	case <-time.After(2 * time.Second):
@@ -114,3 +219,10 @@ func main() {
 		cmd.Process.Kill()
 	}
 }
+
+// logConfigWarnings logs the issues of the config that do not prevent it from running.
+func logConfigWarnings(cfg *config.Config, logger *slog.Logger) {
+	for _, w := range cfg.Warnings() {
+		logger.Warn("Config warning", slog.String("warning", w))
+	}
+}
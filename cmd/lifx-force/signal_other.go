@@ -0,0 +1,19 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+)
+
+// notifyTogglePause is a no-op as SIGUSR1 is not available on this platform.
+func notifyTogglePause(context.Context, *consumer.Consumer) {}
+
+// suspendProcess is not supported on this platform.
+func suspendProcess(*os.Process, bool) error {
+	return errors.ErrUnsupported
+}
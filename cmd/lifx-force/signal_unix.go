@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+)
+
+// notifyTogglePause toggles pause on the consumer each time SIGUSR1 is received,
+// until the context is cancelled.
+func notifyTogglePause(ctx context.Context, c *consumer.Consumer) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigs:
+				c.TogglePause()
+			}
+		}
+	}()
+}
+
+// suspendProcess stops the given process when suspend is set, and continues it otherwise.
+func suspendProcess(p *os.Process, suspend bool) error {
+	if suspend {
+		return p.Signal(syscall.SIGSTOP)
+	}
+	return p.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+)
+
+const shutdownTimeout = 2 * time.Second
+
+type deviceLister interface {
+	GetDevices() []device.Device
+}
+
+// Consumer is the part of the consumer exposed by the API.
+type Consumer interface {
+	Status() consumer.Status
+	Bindings() []config.Binding
+	Trigger(name string) error
+}
+
+// Server is a local HTTP server exposing the devices, the bindings and
+// the status of lifx-force, and allowing named bindings to be triggered.
+type Server struct {
+	cfg      *config.Config
+	ctrl     deviceLister
+	consumer Consumer
+	hub      *stream.Hub
+	// running reports whether fingertrack is running.
+	running func() bool
+	logger  *slog.Logger
+}
+
+func New(cfg *config.Config, ctrl deviceLister, c Consumer, hub *stream.Hub, running func() bool, logger *slog.Logger) *Server {
+	return &Server{cfg: cfg, ctrl: ctrl, consumer: c, hub: hub, running: running, logger: logger}
+}
+
+// Handler returns the API routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", s.handleDevices)
+	mux.HandleFunc("GET /bindings", s.handleBindings)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /bindings/{name}/trigger", s.handleTrigger)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /actions", s.handleActions)
+	if s.cfg.API.Dashboard.Enabled {
+		mux.Handle("GET /{$}", s.handleDashboard())
+		mux.HandleFunc("GET /dashboard/settings", s.handleDashboardSettings)
+	}
+	return s.checkHost(mux)
+}
+
+// checkHost rejects the requests whose Host is a name other than localhost or
+// the configured listen host. A page of another origin could otherwise resolve
+// its own name to the API address, i.e. DNS rebinding, and pass as same-origin.
+// IP addresses cannot be rebound and are always allowed.
+func (s *Server) checkHost(next http.Handler) http.Handler {
+	listenHost, _, _ := net.SplitHostPort(s.cfg.API.Listen)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+		if net.ParseIP(host) == nil && !strings.EqualFold(host, "localhost") && !strings.EqualFold(host, listenHost) {
+			s.writeJSON(w, http.StatusForbidden, errorResponse{Error: "invalid host"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run listens on the configured address until the context is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.cfg.API.Listen)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve serves the API on the given listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("Serving API", slog.String("address", l.Addr().String()))
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+type color struct {
+	Hue        float64 `json:"hue"`
+	Saturation float64 `json:"saturation"`
+	Brightness float64 `json:"brightness"`
+	Kelvin     uint16  `json:"kelvin"`
+}
+
+// Device is the JSON representation of a discovered device.
+type Device struct {
+	Serial     string    `json:"serial"`
+	Label      string    `json:"label"`
+	Product    string    `json:"product"`
+	Type       string    `json:"type"`
+	Group      string    `json:"group"`
+	Location   string    `json:"location"`
+	PoweredOn  bool      `json:"powered_on"`
+	Color      color     `json:"color"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func (s *Server) handleDevices(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, NewDevices(s.ctrl.GetDevices()))
+}
+
+// NewDevices converts the given devices into their JSON representation.
+func NewDevices(devices []device.Device) []Device {
+	resp := make([]Device, len(devices))
+	for i, d := range devices {
+		resp[i] = Device{
+			Serial:     d.Serial.String(),
+			Label:      d.Label,
+			Product:    d.RegistryName,
+			Type:       d.Type.String(),
+			Group:      d.Group,
+			Location:   d.Location,
+			PoweredOn:  d.PoweredOn,
+			Color:      color(d.Color),
+			LastSeenAt: d.LastSeenAt,
+		}
+	}
+	return resp
+}
+
+type selectorResponse struct {
+	Type  config.SelectorType `json:"type"`
+	Value string              `json:"value,omitempty"`
+}
+
+// Binding is the JSON representation of a binding.
+type Binding struct {
+	Name      string                `json:"name,omitempty"`
+	Gesture   config.Gesture        `json:"gesture,omitempty"`
+	Pattern   *config.FingerPattern `json:"pattern,omitempty"`
+	Tolerance int                   `json:"tolerance,omitempty"`
+	Presence  config.Presence       `json:"presence,omitempty"`
+	Count     *int                  `json:"count,omitempty"`
+	Hand      string                `json:"hand,omitempty"`
+	Fallback  bool                  `json:"fallback,omitempty"`
+	Action    config.Action         `json:"action"`
+	Selector  *selectorResponse     `json:"selector,omitempty"`
+}
+
+func (s *Server) handleBindings(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, NewBindings(s.consumer.Bindings()))
+}
+
+// NewBindings converts the given bindings into their JSON representation.
+func NewBindings(bindings []config.Binding) []Binding {
+	resp := make([]Binding, len(bindings))
+	for i, b := range bindings {
+		resp[i] = Binding{
+			Name:      b.Name,
+			Gesture:   b.Gesture,
+			Pattern:   b.Pattern,
+			Tolerance: b.Tolerance,
+			Presence:  b.Presence,
+			Count:     b.Count,
+			Hand:      b.Hand,
+			Fallback:  b.Fallback,
+			Action:    b.Action,
+		}
+		if b.Selector.Type != "" {
+			resp[i].Selector = &selectorResponse{Type: b.Selector.Type, Value: b.Selector.Value}
+		}
+	}
+	return resp
+}
+
+type statusResponse struct {
+	FingertrackRunning bool          `json:"fingertrack_running"`
+	LastEvent          *time.Time    `json:"last_event"`
+	Mode               consumer.Mode `json:"mode"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	status := s.consumer.Status()
+	resp := statusResponse{FingertrackRunning: s.running(), Mode: status.Mode}
+	if !status.LastEvent.IsZero() {
+		resp.LastEvent = &status.LastEvent
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		s.writeJSON(w, http.StatusForbidden, errorResponse{Error: "cross-origin request"})
+		return
+	}
+	if fromBrowser(r) && !s.cfg.API.Dashboard.AllowTrigger {
+		s.writeJSON(w, http.StatusForbidden, errorResponse{Error: "dashboard is read-only"})
+		return
+	}
+	name := r.PathValue("name")
+	if err := s.consumer.Trigger(name); err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, consumer.ErrBindingNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, consumer.ErrNotTriggerable):
+			code = http.StatusUnprocessableEntity
+		}
+		s.writeJSON(w, code, errorResponse{Error: err.Error()})
+		return
+	}
+	s.logger.Info("Triggered binding from API", slog.String("binding", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sameOrigin reports whether the request was not sent by a page of another
+// origin. Browsers set the Origin header on cross-origin and POST requests,
+// which would otherwise let any website trigger bindings without a preflight.
+// Requests without the header, e.g. from curl, are allowed.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Debug("failed to write response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockController struct {
+	devices []device.Device
+}
+
+func (m *mockController) GetDevices() []device.Device {
+	return m.devices
+}
+
+type mockConsumer struct {
+	status    consumer.Status
+	bindings  []config.Binding
+	triggered []string
+	err       error
+}
+
+func (m *mockConsumer) Bindings() []config.Binding {
+	return m.bindings
+}
+
+func (m *mockConsumer) Status() consumer.Status {
+	return m.status
+}
+
+func (m *mockConsumer) Trigger(name string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.triggered = append(m.triggered, name)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		lastSeen   = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		pattern    = config.FingerPattern{0, 1, 1, 0, 0}
+		cfg        = &config.Config{API: config.API{Listen: "lights.lan:8787", Dashboard: config.Dashboard{Enabled: true, AllowTrigger: true}}}
+		bindings   = []config.Binding{
+			{Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeAll}},
+			{Pattern: &pattern, Action: config.ActionCancelTimers},
+		}
+		ctrl = &mockController{devices: []device.Device{{
+			Serial:     serial0,
+			Label:      "Lamp",
+			Group:      "Bedroom",
+			PoweredOn:  true,
+			Color:      device.Color{Brightness: 50, Kelvin: 2700},
+			LastSeenAt: lastSeen,
+		}}}
+	)
+
+	testCases := map[string]struct {
+		consumer  *mockConsumer
+		method    string
+		path      string
+		host      string
+		origin    string
+		wantCode  int
+		wantBody  string
+		triggered []string
+	}{
+		"devices": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/devices",
+			wantCode: http.StatusOK,
+			wantBody: `[{"serial":"d073d5000000","label":"Lamp","product":"","type":"light","group":"Bedroom","location":"",
+				"powered_on":true,"color":{"hue":0,"saturation":0,"brightness":50,"kelvin":2700},"last_seen_at":"2025-01-01T12:00:00Z"}]`,
+		},
+		"bindings": {
+			consumer: &mockConsumer{bindings: bindings},
+			method:   http.MethodGet,
+			path:     "/bindings",
+			wantCode: http.StatusOK,
+			wantBody: `[{"name":"off","gesture":"swipe_down","action":"power_off","selector":{"type":"all"}},
+				{"pattern":[0,1,1,0,0],"action":"cancel_timers"}]`,
+		},
+		"status": {
+			consumer: &mockConsumer{status: consumer.Status{LastEvent: lastSeen, Mode: consumer.ModeArmed}},
+			method:   http.MethodGet,
+			path:     "/status",
+			wantCode: http.StatusOK,
+			wantBody: `{"fingertrack_running":true,"last_event":"2025-01-01T12:00:00Z","mode":"armed"}`,
+		},
+		"status without events": {
+			consumer: &mockConsumer{status: consumer.Status{Mode: consumer.ModeActive}},
+			method:   http.MethodGet,
+			path:     "/status",
+			wantCode: http.StatusOK,
+			wantBody: `{"fingertrack_running":true,"last_event":null,"mode":"active"}`,
+		},
+		"trigger": {
+			consumer:  &mockConsumer{},
+			method:    http.MethodPost,
+			path:      "/bindings/off/trigger",
+			wantCode:  http.StatusNoContent,
+			triggered: []string{"off"},
+		},
+		"trigger unknown binding": {
+			consumer: &mockConsumer{err: consumer.ErrBindingNotFound},
+			method:   http.MethodPost,
+			path:     "/bindings/on/trigger",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"binding not found"}`,
+		},
+		"trigger binding needing a hand": {
+			consumer: &mockConsumer{err: consumer.ErrNotTriggerable},
+			method:   http.MethodPost,
+			path:     "/bindings/off/trigger",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"error":"binding needs a hand position and cannot be triggered manually"}`,
+		},
+		"trigger failure": {
+			consumer: &mockConsumer{err: errors.New("send failed")},
+			method:   http.MethodPost,
+			path:     "/bindings/off/trigger",
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"error":"send failed"}`,
+		},
+		"trigger from same origin": {
+			consumer:  &mockConsumer{},
+			method:    http.MethodPost,
+			path:      "/bindings/off/trigger",
+			origin:    "http://localhost",
+			wantCode:  http.StatusNoContent,
+			triggered: []string{"off"},
+		},
+		"trigger from other origin": {
+			consumer: &mockConsumer{},
+			method:   http.MethodPost,
+			path:     "/bindings/off/trigger",
+			origin:   "http://attacker.example",
+			wantCode: http.StatusForbidden,
+			wantBody: `{"error":"cross-origin request"}`,
+		},
+		"trigger from opaque origin": {
+			consumer: &mockConsumer{},
+			method:   http.MethodPost,
+			path:     "/bindings/off/trigger",
+			origin:   "null",
+			wantCode: http.StatusForbidden,
+			wantBody: `{"error":"cross-origin request"}`,
+		},
+		"rebound host": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/devices",
+			host:     "attacker.example:8787",
+			wantCode: http.StatusForbidden,
+			wantBody: `{"error":"invalid host"}`,
+		},
+		"rebound host events": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/events",
+			host:     "attacker.example:8787",
+			wantCode: http.StatusForbidden,
+			wantBody: `{"error":"invalid host"}`,
+		},
+		"rebound host trigger from its own origin": {
+			consumer: &mockConsumer{},
+			method:   http.MethodPost,
+			path:     "/bindings/off/trigger",
+			host:     "attacker.example:8787",
+			origin:   "http://attacker.example:8787",
+			wantCode: http.StatusForbidden,
+			wantBody: `{"error":"invalid host"}`,
+		},
+		"listen host": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/status",
+			host:     "lights.lan:8787",
+			wantCode: http.StatusOK,
+		},
+		"ip host": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/status",
+			host:     "[::1]:8787",
+			wantCode: http.StatusOK,
+		},
+		"trigger requires post": {
+			consumer: &mockConsumer{},
+			method:   http.MethodGet,
+			path:     "/bindings/off/trigger",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s := New(cfg, ctrl, tc.consumer, stream.NewHub(stream.DefaultBufferSize, logger.NewLogger(slog.LevelInfo, "")), func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "http://localhost"+tc.path, nil)
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			s.Handler().ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			assert.Equal(t, tc.triggered, tc.consumer.triggered)
+		})
+	}
+}
+
+func TestServerDevicesEmpty(t *testing.T) {
+	s := New(&config.Config{}, &mockController{}, &mockConsumer{}, nil, func() bool { return false }, logger.NewLogger(slog.LevelInfo, ""))
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/devices", nil))
+
+	var devices []Device
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&devices))
+	assert.NotNil(t, devices)
+	assert.Empty(t, devices)
+}
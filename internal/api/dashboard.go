@@ -0,0 +1,44 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+)
+
+//go:embed dashboard
+var dashboardFS embed.FS
+
+type dashboardSettings struct {
+	AllowTrigger bool `json:"allow_trigger"`
+}
+
+// handleDashboard serves the embedded single page dashboard.
+func (s *Server) handleDashboard() http.Handler {
+	sub, err := fs.Sub(dashboardFS, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(sub)
+}
+
+func (s *Server) handleDashboardSettings(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, dashboardSettings{AllowTrigger: s.cfg.API.Dashboard.AllowTrigger})
+}
+
+// fromBrowser reports whether the request was sent by a web page. Once
+// cross-origin requests are rejected, these can only come from the dashboard,
+// which may only trigger bindings when allowed.
+func fromBrowser(r *http.Request) bool {
+	return r.Header.Get("Origin") != ""
+}
+
+func (s *Server) handleActions(w http.ResponseWriter, _ *http.Request) {
+	actions := s.hub.RecentActions()
+	if actions == nil {
+		actions = []consumer.Record{}
+	}
+	s.writeJSON(w, http.StatusOK, actions)
+}
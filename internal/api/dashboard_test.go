@@ -0,0 +1,128 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerDashboard(t *testing.T) {
+	testCases := map[string]struct {
+		dashboard config.Dashboard
+		path      string
+		wantCode  int
+		wantBody  string
+	}{
+		"index": {
+			dashboard: config.Dashboard{Enabled: true},
+			path:      "/",
+			wantCode:  http.StatusOK,
+		},
+		"disabled": {
+			path:     "/",
+			wantCode: http.StatusNotFound,
+		},
+		"read-only settings": {
+			dashboard: config.Dashboard{Enabled: true},
+			path:      "/dashboard/settings",
+			wantCode:  http.StatusOK,
+			wantBody:  `{"allow_trigger":false}`,
+		},
+		"trigger settings": {
+			dashboard: config.Dashboard{Enabled: true, AllowTrigger: true},
+			path:      "/dashboard/settings",
+			wantCode:  http.StatusOK,
+			wantBody:  `{"allow_trigger":true}`,
+		},
+		"settings disabled": {
+			path:     "/dashboard/settings",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{API: config.API{Dashboard: tc.dashboard}}
+			s := New(cfg, &mockController{}, &mockConsumer{}, nil, func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+tc.path, nil))
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			if tc.path == "/" && tc.wantCode == http.StatusOK {
+				assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+				assert.Contains(t, rec.Body.String(), "<title>lifx-force</title>")
+			}
+		})
+	}
+}
+
+func TestServerDashboardTrigger(t *testing.T) {
+	testCases := map[string]struct {
+		dashboard config.Dashboard
+		origin    string
+		wantCode  int
+		triggered []string
+	}{
+		"allowed from dashboard": {
+			dashboard: config.Dashboard{Enabled: true, AllowTrigger: true},
+			origin:    "http://localhost",
+			wantCode:  http.StatusNoContent,
+			triggered: []string{"off"},
+		},
+		"read-only dashboard": {
+			dashboard: config.Dashboard{Enabled: true},
+			origin:    "http://localhost",
+			wantCode:  http.StatusForbidden,
+		},
+		"read-only dashboard allows other clients": {
+			dashboard: config.Dashboard{Enabled: true},
+			wantCode:  http.StatusNoContent,
+			triggered: []string{"off"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{API: config.API{Dashboard: tc.dashboard}}
+			c := &mockConsumer{}
+			s := New(cfg, &mockController{}, c, nil, func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "http://localhost/bindings/off/trigger", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			s.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.triggered, c.triggered)
+		})
+	}
+}
+
+func TestServerActions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	hub := stream.NewHub(stream.DefaultBufferSize, logger.NewLogger(slog.LevelInfo, ""))
+	s := New(&config.Config{}, &mockController{}, &mockConsumer{}, hub, func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/actions", nil))
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	hub.Record(consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{}})
+	hub.Record(consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Binding: "off", Serials: []string{}}})
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/actions", nil))
+	assert.JSONEq(t, `[{"type":"action","time":"2025-01-01T12:00:00Z","action":{"binding":"off","serials":[]}}]`, rec.Body.String())
+}
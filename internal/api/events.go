@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const writeTimeout = 5 * time.Second
+
+// handleEvents streams the records of the consumer over a WebSocket.
+// The optional type (event, action) and hand (left, right) query
+// parameters restrict the records sent to the client.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		s.logger.Debug("failed to accept websocket", slog.Any("error", err))
+		return
+	}
+	defer conn.CloseNow()
+
+	sub := s.hub.Subscribe(filter)
+	defer s.hub.Unsubscribe(sub)
+	s.logger.Debug("stream client connected", slog.String("remote", r.RemoteAddr))
+
+	// Messages from the client are discarded, the context is
+	// cancelled once the connection is closed.
+	ctx := conn.CloseRead(r.Context())
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Debug("stream client disconnected", slog.String("remote", r.RemoteAddr), slog.Int64("dropped", sub.Dropped()))
+			return
+		case rec, ok := <-sub.C:
+			if !ok {
+				conn.Close(websocket.StatusGoingAway, "")
+				return
+			}
+			if err := s.writeRecord(ctx, conn, rec); err != nil {
+				s.logger.Debug("failed to write record", slog.Any("error", err))
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) writeRecord(ctx context.Context, conn *websocket.Conn, rec consumer.Record) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, rec)
+}
+
+func parseFilter(r *http.Request) (stream.Filter, error) {
+	var f stream.Filter
+	q := r.URL.Query()
+	if v := q.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			switch rt := consumer.RecordType(t); rt {
+			case consumer.RecordTypeEvent, consumer.RecordTypeAction, consumer.RecordTypeMode:
+				f.Types = append(f.Types, rt)
+			default:
+				return f, fmt.Errorf("type must be one of event, action, mode")
+			}
+		}
+	}
+	switch h := q.Get("hand"); h {
+	case "", consumer.LeftHandLabel, consumer.RightHandLabel:
+		f.Hand = h
+	default:
+		return f, fmt.Errorf("hand must be one of left, right")
+	}
+	return f, nil
+}
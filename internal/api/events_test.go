@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerEvents(t *testing.T) {
+	var (
+		now    = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		left   = consumer.Hand{Label: consumer.LeftHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}}
+		right  = consumer.Hand{Label: consumer.RightHandLabel, Gesture: config.GestureSwipeUp}
+		event  = consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{left, right}}}
+		action = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{
+			Binding: "up", Gesture: config.GestureSwipeUp, Hand: "right", Serials: []string{"d073d5000000"},
+		}}
+	)
+
+	testCases := map[string]struct {
+		query string
+		want  []consumer.Record
+	}{
+		"all records": {
+			want: []consumer.Record{event, action},
+		},
+		"actions only": {
+			query: "?type=action",
+			want:  []consumer.Record{action},
+		},
+		"left hand only": {
+			query: "?hand=left",
+			want:  []consumer.Record{{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{left}}}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			hub := stream.NewHub(stream.DefaultBufferSize, logger.NewLogger(slog.LevelInfo, ""))
+			s := New(&config.Config{}, &mockController{}, &mockConsumer{}, hub, func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+			srv := httptest.NewServer(s.Handler())
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/events"+tc.query, nil)
+			require.NoError(t, err)
+			defer conn.CloseNow()
+
+			// Wait for the subscription before publishing.
+			require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+			hub.Record(event)
+			hub.Record(action)
+
+			for _, want := range tc.want {
+				var got consumer.Record
+				require.NoError(t, wsjson.Read(ctx, conn, &got))
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+
+	t.Run("invalid filter", func(t *testing.T) {
+		s := New(&config.Config{}, &mockController{}, &mockConsumer{}, nil, func() bool { return true }, logger.NewLogger(slog.LevelInfo, ""))
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/events?hand=middle", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"error":"hand must be one of left, right"}`, rec.Body.String())
+	})
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+)
+
+// Condition checks the cached state of the devices targeted by a binding.
+// Power is one of on or off, while Brightness (0-100) and Kelvin (1500-9000)
+// are inclusive ranges where either bound may be omitted.
+type Condition struct {
+	Power      string `toml:"power,omitempty"`
+	Brightness *Range `toml:"brightness,omitempty"`
+	Kelvin     *Range `toml:"kelvin,omitempty"`
+}
+
+type Range struct {
+	Min *float64 `toml:"min,omitempty"`
+	Max *float64 `toml:"max,omitempty"`
+}
+
+// Else is the action run when the condition of a binding is false.
+type Else struct {
+	Action Action `toml:"action"`
+	HSBK   *HSBK  `toml:"hsbk,omitempty"`
+}
+
+func (c *Condition) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Power == "" && c.Brightness == nil && c.Kelvin == nil {
+		return fmt.Errorf("if: one of power, brightness or kelvin is required")
+	}
+	switch c.Power {
+	case "", "on", "off":
+	default:
+		return fmt.Errorf("if.power must be one of on, off")
+	}
+	if err := c.Brightness.validate(0, 100); err != nil {
+		return fmt.Errorf("if.brightness: %w", err)
+	}
+	if err := c.Kelvin.validate(1500, 9000); err != nil {
+		return fmt.Errorf("if.kelvin: %w", err)
+	}
+	return nil
+}
+
+func (r *Range) validate(lower, upper float64) error {
+	if r == nil {
+		return nil
+	}
+	if r.Min == nil && r.Max == nil {
+		return fmt.Errorf("one of min or max is required")
+	}
+	for _, v := range []*float64{r.Min, r.Max} {
+		if v != nil && (*v < lower || *v > upper) {
+			return fmt.Errorf("invalid value [%v], must be %v-%v", *v, lower, upper)
+		}
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return fmt.Errorf("min must be <= max")
+	}
+	return nil
+}
+
+// Match returns whether the given device state satisfies the condition.
+func (c *Condition) Match(d *device.Device) bool {
+	switch c.Power {
+	case "on":
+		if !d.PoweredOn {
+			return false
+		}
+	case "off":
+		if d.PoweredOn {
+			return false
+		}
+	}
+	return c.Brightness.contains(d.Color.Brightness) && c.Kelvin.contains(float64(d.Color.Kelvin))
+}
+
+func (r *Range) contains(v float64) bool {
+	if r == nil {
+		return true
+	}
+	return (r.Min == nil || v >= *r.Min) && (r.Max == nil || v <= *r.Max)
+}
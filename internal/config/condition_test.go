@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionMatch(t *testing.T) {
+	var (
+		low, high float64 = 20, 60
+		warm              = 3000.0
+		on                = &device.Device{PoweredOn: true, Color: device.Color{Brightness: 50, Kelvin: 2700}}
+		off               = &device.Device{PoweredOn: false, Color: device.Color{Brightness: 100, Kelvin: 6500}}
+	)
+
+	testCases := map[string]struct {
+		cond *Condition
+		d    *device.Device
+		want bool
+	}{
+		"power on":             {cond: &Condition{Power: "on"}, d: on, want: true},
+		"power on mismatch":    {cond: &Condition{Power: "on"}, d: off, want: false},
+		"power off":            {cond: &Condition{Power: "off"}, d: off, want: true},
+		"brightness in range":  {cond: &Condition{Brightness: &Range{Min: &low, Max: &high}}, d: on, want: true},
+		"brightness above max": {cond: &Condition{Brightness: &Range{Max: &high}}, d: off, want: false},
+		"kelvin below max":     {cond: &Condition{Kelvin: &Range{Max: &warm}}, d: on, want: true},
+		"kelvin above max":     {cond: &Condition{Kelvin: &Range{Max: &warm}}, d: off, want: false},
+		"all fields match":     {cond: &Condition{Power: "on", Brightness: &Range{Min: &low}, Kelvin: &Range{Max: &warm}}, d: on, want: true},
+		"one field mismatch":   {cond: &Condition{Power: "on", Brightness: &Range{Min: &high}}, d: on, want: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.cond.Match(tc.d))
+		})
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
@@ -19,13 +22,60 @@ const (
 
 	defaultLogLevel = "info"
 
+	defaultIdleTimeoutMs = 600000
+	defaultFadeMs        = 30000
+
+	defaultHoldMs         = 1000
+	defaultArmedTimeoutMs = 10000
+
+	defaultMaxPendingTimers = 8
+
+	defaultMaxConcurrentExec = 4
+	defaultMQTTClientID      = "lifx-force"
+	defaultMQTTTopicPrefix   = "lifx-force"
+	defaultMQTTMinBackoffMs  = 1000
+	defaultMQTTMaxBackoffMs  = 60000
+	defaultDiscoveryPrefix   = "homeassistant"
+	defaultOSCPort           = 9000
+
 	defaultFrameSkip        = 1
 	defaultBufferSize       = 5
+	defaultStableFrames     = 1
 	defaultGestureThreshold = 0.1
 )
 
 type FingerPattern [5]int
 
+// Count returns the number of fingers up in the pattern.
+func (p FingerPattern) Count() int {
+	var n int
+	for _, f := range p {
+		n += f
+	}
+	return n
+}
+
+// String formats the pattern as a string of 0s and 1s, e.g. 01100,
+// as used in logs, MQTT topics and exec placeholders.
+func (p FingerPattern) String() string {
+	b := make([]byte, 0, len(p))
+	for _, f := range p {
+		b = strconv.AppendInt(b, int64(f), 10)
+	}
+	return string(b)
+}
+
+// Distance returns the number of fingers differing between the patterns.
+func (p FingerPattern) Distance(q FingerPattern) int {
+	var n int
+	for i := range p {
+		if p[i] != q[i] {
+			n++
+		}
+	}
+	return n
+}
+
 type Gesture string
 
 const (
@@ -52,12 +102,84 @@ var supportedGestures = map[Gesture]struct{}{
 	GesturePushDown: {},
 }
 
+// IsCompound returns whether the gesture is made with both hands.
+func (g Gesture) IsCompound() bool {
+	switch g {
+	case GestureExpand, GestureContract, GesturePullUp, GesturePushDown:
+		return true
+	}
+	return false
+}
+
+type Presence string
+
+const (
+	// PresenceEnter triggers when hands appear after none were detected.
+	PresenceEnter Presence = "enter"
+	// PresenceLeave triggers when all hands are gone.
+	PresenceLeave Presence = "leave"
+)
+
 type Action string
 
 const (
 	ActionPowerOn       Action = "power_on"
 	ActionPowerOff      Action = "power_off"
 	ActionPowerSetColor Action = "set_color"
+	// ActionFlash briefly pulses the HSBK colour, after which the devices return to their colour.
+	ActionFlash Action = "flash"
+	// ActionScheduleAction runs the binding Schedule action after a delay.
+	ActionScheduleAction Action = "schedule_action"
+	// ActionCancelTimers cancels all pending scheduled actions.
+	ActionCancelTimers Action = "cancel_timers"
+	// ActionExec runs the binding Exec command.
+	ActionExec Action = "exec"
+	// ActionWebhook posts the trigger of the binding to the Webhook URL.
+	ActionWebhook Action = "webhook"
+	// ActionTogglePause pauses or resumes the handling of bindings.
+	ActionTogglePause Action = "toggle_pause"
+	// ActionDial sets a property of the devices from a hand position while the binding pattern or count is held.
+	ActionDial Action = "dial"
+)
+
+// DialSource is the hand position driving a dial, normalised by fingertrack to 0-1.
+type DialSource string
+
+const (
+	DialSourceWristY DialSource = "wrist_y"
+	DialSourcePalmX  DialSource = "palm_x"
+	DialSourcePinch  DialSource = "pinch"
+)
+
+// DialProperty is the device property set by a dial.
+type DialProperty string
+
+const (
+	DialPropertyBrightness DialProperty = "brightness"
+	DialPropertyHue        DialProperty = "hue"
+	DialPropertyKelvin     DialProperty = "kelvin"
+)
+
+// Range returns the values accepted by the property.
+func (p DialProperty) Range() (float64, float64) {
+	switch p {
+	case DialPropertyHue:
+		return 0, 360
+	case DialPropertyKelvin:
+		return 1500, 9000
+	}
+	return 0, 100
+}
+
+// DialCurve shapes how positions are mapped to values.
+type DialCurve string
+
+const (
+	DialCurveLinear DialCurve = "linear"
+	// DialCurveEaseIn gives finer control at the start of the range.
+	DialCurveEaseIn DialCurve = "ease_in"
+	// DialCurveEaseOut gives finer control at the end of the range.
+	DialCurveEaseOut DialCurve = "ease_out"
 )
 
 type SelectorType string
@@ -68,13 +190,25 @@ const (
 	SelectorTypeGroup    SelectorType = "group"
 	SelectorTypeLocation SelectorType = "location"
 	SelectorTypeSerial   SelectorType = "serial"
+	// SelectorTypeZone targets the devices of the zone the hand is in when the binding fires.
+	SelectorTypeZone SelectorType = "zone"
 )
 
 type Config struct {
-	General  General   `toml:"general"`
-	Logging  Logging   `toml:"logging"`
-	Tracking Tracking  `toml:"tracking"`
-	Bindings []Binding `toml:"bindings"`
+	General   General   `toml:"general"`
+	Logging   Logging   `toml:"logging"`
+	Tracking  Tracking  `toml:"tracking"`
+	Occupancy Occupancy `toml:"occupancy"`
+	Arming    Arming    `toml:"arming"`
+	Timers    Timers    `toml:"timers"`
+	Exec      ExecLimit `toml:"exec"`
+	API       API       `toml:"api"`
+	MQTT      MQTT      `toml:"mqtt"`
+	OSC       OSC       `toml:"osc"`
+	Control   Control   `toml:"control"`
+	Feedback  Feedback  `toml:"feedback"`
+	Zones     []Zone    `toml:"zones"`
+	Bindings  []Binding `toml:"bindings"`
 }
 
 type General struct {
@@ -85,6 +219,182 @@ type Tracking struct {
 	FrameSkip  int  `toml:"frame_skip"`
 	BufferSize int  `toml:"buffer_size"`
 	Preview    bool `toml:"preview"`
+	// StableFrames is the number of consecutive events a finger pattern
+	// must be reported for the same hand before it is considered observed.
+	StableFrames int `toml:"stable_frames"`
+	// MirrorHorizontal swaps left and right, FlipVertical swaps up and down,
+	// to match the camera orientation. Hand positions are mirrored as well.
+	MirrorHorizontal bool `toml:"mirror_horizontal"`
+	FlipVertical     bool `toml:"flip_vertical"`
+	// SuspendWhenPaused stops fingertrack while bindings are paused.
+	SuspendWhenPaused bool `toml:"suspend_when_paused"`
+}
+
+// Occupancy treats fingertrack as a presence sensor, running Action on the
+// selected devices when no hands are seen for IdleTimeoutMs, over FadeMs.
+// Action is either power_off or set_color, e.g. to dim the devices with HSBK.
+// FadeMs is a pointer so that 0 is not replaced by the default.
+type Occupancy struct {
+	Enabled       bool     `toml:"enabled"`
+	IdleTimeoutMs int      `toml:"idle_timeout_ms"`
+	FadeMs        *int     `toml:"fade_ms"`
+	Action        Action   `toml:"action"`
+	HSBK          *HSBK    `toml:"hsbk,omitempty"`
+	Selector      Selector `toml:"selector"`
+}
+
+// Fade returns the duration of the idle action.
+func (o *Occupancy) Fade() time.Duration {
+	if o.FadeMs == nil {
+		return 0
+	}
+	return time.Duration(*o.FadeMs) * time.Millisecond
+}
+
+// Arming requires a wake gesture or pattern before any other gesture or
+// pattern binding is handled. Once armed, bindings are handled for ArmedTimeoutMs.
+// The wake pattern must be held for HoldMs, while a wake gesture arms as soon
+// as it is detected. HoldMs is a pointer so that 0 is not replaced by the default.
+type Arming struct {
+	Enabled        bool           `toml:"enabled"`
+	Gesture        Gesture        `toml:"gesture,omitempty"`
+	Pattern        *FingerPattern `toml:"pattern,omitempty"`
+	HoldMs         *int           `toml:"hold_ms"`
+	ArmedTimeoutMs int            `toml:"armed_timeout_ms"`
+	RearmOnAction  bool           `toml:"rearm_on_action"`
+	Indicator      *Indicator     `toml:"indicator,omitempty"`
+}
+
+// Hold returns the time the wake pattern must be held for.
+func (a *Arming) Hold() time.Duration {
+	if a.HoldMs == nil {
+		return 0
+	}
+	return time.Duration(*a.HoldMs) * time.Millisecond
+}
+
+// Indicator defines the devices flashed when arming or disarming,
+// a nil HSBK disables the flash for that state change.
+type Indicator struct {
+	Selector   Selector `toml:"selector"`
+	ArmHSBK    *HSBK    `toml:"arm_hsbk,omitempty"`
+	DisarmHSBK *HSBK    `toml:"disarm_hsbk,omitempty"`
+}
+
+// Feedback briefly pulses HSBK when a binding is actioned, or ErrorHSBK when
+// sending to its devices fails, on the Selector devices, or on the devices
+// targeted by the binding when no selector is set.
+type Feedback struct {
+	Enabled   bool      `toml:"enabled"`
+	Selector  *Selector `toml:"selector,omitempty"`
+	HSBK      *HSBK     `toml:"hsbk,omitempty"`
+	ErrorHSBK *HSBK     `toml:"error_hsbk,omitempty"`
+}
+
+// Zone is a named area of the camera frame, in coordinates normalised to 0-1,
+// targeting the devices matching its selector. The area spans the full height
+// of the frame when YMin and YMax are unset.
+type Zone struct {
+	Name     string   `toml:"name"`
+	XMin     float64  `toml:"x_min"`
+	XMax     float64  `toml:"x_max"`
+	YMin     float64  `toml:"y_min,omitempty"`
+	YMax     float64  `toml:"y_max,omitempty"`
+	Selector Selector `toml:"selector"`
+}
+
+// YRange returns the vertical range of the zone.
+func (z *Zone) YRange() (float64, float64) {
+	if z.YMin == z.YMax {
+		return 0, 1
+	}
+	return z.YMin, z.YMax
+}
+
+// Contains returns whether the given position is within the zone, edges included.
+func (z *Zone) Contains(x, y float64) bool {
+	yMin, yMax := z.YRange()
+	return x >= z.XMin && x <= z.XMax && y >= yMin && y <= yMax
+}
+
+// Overlaps returns whether the zones share an area, zones sharing an edge do not overlap.
+func (z *Zone) Overlaps(o *Zone) bool {
+	yMin, yMax := z.YRange()
+	oyMin, oyMax := o.YRange()
+	return z.XMin < o.XMax && o.XMin < z.XMax && yMin < oyMax && oyMin < yMax
+}
+
+// Timers configures the actions scheduled by schedule_action bindings.
+// When PersistFile is set, pending timers are saved to it and resumed on start.
+type Timers struct {
+	MaxPending  int    `toml:"max_pending"`
+	PersistFile string `toml:"persist_file"`
+}
+
+// ExecLimit caps the number of commands run concurrently by exec bindings.
+type ExecLimit struct {
+	MaxConcurrent int `toml:"max_concurrent"`
+}
+
+// API configures the local HTTP control and status server,
+// which is only started when listen is set.
+type API struct {
+	Listen    string    `toml:"listen"`
+	Dashboard Dashboard `toml:"dashboard"`
+}
+
+// Dashboard configures the web UI served by the API, which is read-only
+// unless triggering bindings is allowed. While read-only, the API rejects
+// the triggers sent by browsers.
+type Dashboard struct {
+	Enabled      bool `toml:"enabled"`
+	AllowTrigger bool `toml:"allow_trigger"`
+}
+
+// MQTT configures the connection to an MQTT broker, used to publish
+// gestures and actioned bindings and to receive commands.
+// It is only enabled when broker is set.
+type MQTT struct {
+	Broker       string    `toml:"broker"`
+	ClientID     string    `toml:"client_id"`
+	Username     string    `toml:"username"`
+	Password     string    `toml:"password"`
+	TopicPrefix  string    `toml:"topic_prefix"`
+	QoS          int       `toml:"qos"`
+	MinBackoffMs int       `toml:"min_backoff_ms"`
+	MaxBackoffMs int       `toml:"max_backoff_ms"`
+	TLS          *MQTTTLS  `toml:"tls,omitempty"`
+	Discovery    Discovery `toml:"discovery"`
+}
+
+// Discovery configures the Home Assistant MQTT discovery of the
+// gesture and pattern bindings as device triggers.
+type Discovery struct {
+	Enabled bool   `toml:"enabled"`
+	Prefix  string `toml:"prefix"`
+}
+
+// MQTTTLS configures TLS for the broker connection. The system roots are
+// used when no CA file is set, and a client certificate is optional.
+type MQTTTLS struct {
+	CAFile             string `toml:"ca_file"`
+	CertFile           string `toml:"cert_file"`
+	KeyFile            string `toml:"key_file"`
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+}
+
+// OSC configures the target of the OSC messages sent over UDP
+// for every event, which are only sent when host is set.
+type OSC struct {
+	Host string `toml:"host"`
+	Port int    `toml:"port"`
+}
+
+// Control configures the local control interface, a Unix socket
+// defaulting to control.sock in the lifx-force directory.
+type Control struct {
+	Enabled bool   `toml:"enabled"`
+	Socket  string `toml:"socket"`
 }
 
 type Logging struct {
@@ -93,11 +403,125 @@ type Logging struct {
 }
 
 type Binding struct {
-	Gesture  Gesture        `toml:"gesture,omitempty"`
-	Pattern  *FingerPattern `toml:"pattern,omitempty"`
-	Action   Action         `toml:"action"`
-	Selector Selector       `toml:"selector"`
-	HSBK     *HSBK          `toml:"hsbk,omitempty"`
+	Name    string         `toml:"name,omitempty"`
+	Gesture Gesture        `toml:"gesture,omitempty"`
+	Pattern *FingerPattern `toml:"pattern,omitempty"`
+	// Tolerance is the number of fingers a pattern may differ by and still
+	// match, the closest pattern winning when several bindings match.
+	Tolerance int      `toml:"tolerance,omitempty"`
+	Presence  Presence `toml:"presence,omitempty"`
+	// Count matches any pattern with this number of fingers up, from Hand
+	// when set, unless a binding matches the exact pattern.
+	Count *int   `toml:"count,omitempty"`
+	Hand  string `toml:"hand,omitempty"`
+	// Fallback runs the binding when a gesture is detected that no other binding matches.
+	Fallback bool       `toml:"fallback,omitempty"`
+	AfterMs  int        `toml:"after_ms,omitempty"`
+	When     *When      `toml:"when,omitempty"`
+	If       *Condition `toml:"if,omitempty"`
+	Action   Action     `toml:"action"`
+	Selector Selector   `toml:"selector"`
+	HSBK     *HSBK      `toml:"hsbk,omitempty"`
+	Else     *Else      `toml:"else,omitempty"`
+	Schedule *Schedule  `toml:"schedule,omitempty"`
+	Exec     *Exec      `toml:"exec,omitempty"`
+	Webhook  *Webhook   `toml:"webhook,omitempty"`
+	Dial     *Dial      `toml:"dial,omitempty"`
+	// TransitionMs overrides general.transition_ms for this binding when set.
+	TransitionMs int `toml:"transition_ms,omitempty"`
+	// Feedback overrides the global feedback for this binding when set.
+	Feedback *Feedback `toml:"feedback,omitempty"`
+}
+
+// TriggerID returns the id of the device trigger advertised for the binding,
+// its name when set, otherwise its gesture or pattern. It is empty for
+// bindings that cannot be told apart from their actions: presence, fallback
+// and unnamed count bindings.
+func (b *Binding) TriggerID() string {
+	switch {
+	case b.Name != "" && (b.Gesture != "" || b.Pattern != nil || b.Count != nil):
+		return SanitizeID(b.Name)
+	case b.Gesture != "":
+		return "gesture_" + string(b.Gesture)
+	case b.Pattern != nil:
+		return "pattern_" + b.Pattern.String()
+	}
+	return ""
+}
+
+// SanitizeID replaces the characters not allowed in Home Assistant ids.
+func SanitizeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Dial maps the Source position of a hand, from InputMin to InputMax, to the
+// Property of the devices, from OutputMin to OutputMax, along the Curve.
+// The input range defaults to 0-1 and the output range to the full range of
+// the property when their bounds are equal. Values within Deadband of the last
+// value sent are ignored and updates are sent at most every RateLimitMs.
+type Dial struct {
+	Source      DialSource   `toml:"source"`
+	Property    DialProperty `toml:"property"`
+	InputMin    float64      `toml:"input_min,omitempty"`
+	InputMax    float64      `toml:"input_max,omitempty"`
+	OutputMin   float64      `toml:"output_min,omitempty"`
+	OutputMax   float64      `toml:"output_max,omitempty"`
+	Curve       DialCurve    `toml:"curve,omitempty"`
+	Deadband    float64      `toml:"deadband,omitempty"`
+	RateLimitMs int          `toml:"rate_limit_ms,omitempty"`
+}
+
+// InputRange returns the range of positions mapped by the dial.
+func (d *Dial) InputRange() (float64, float64) {
+	if d.InputMin == d.InputMax {
+		return 0, 1
+	}
+	return d.InputMin, d.InputMax
+}
+
+// OutputRange returns the range of values set by the dial, which is
+// reversed when OutputMin is greater than OutputMax.
+func (d *Dial) OutputRange() (float64, float64) {
+	if d.OutputMin == d.OutputMax {
+		return d.Property.Range()
+	}
+	return d.OutputMin, d.OutputMax
+}
+
+// Exec is the command run by an exec binding. Args may contain the
+// {gesture}, {hand}, {fingers} and {presence} placeholders, replaced with
+// the values of the trigger. Only the environment variables listed in Env
+// are passed to the command.
+type Exec struct {
+	Command   string   `toml:"command"`
+	Args      []string `toml:"args,omitempty"`
+	TimeoutMs int      `toml:"timeout_ms,omitempty"`
+	Env       []string `toml:"env,omitempty"`
+}
+
+// Webhook is the request sent by a webhook binding, posting a JSON description
+// of the trigger to URL. Failed requests are retried up to Retries times,
+// waiting BackoffMs before the first retry and doubling it after each one.
+type Webhook struct {
+	URL       string            `toml:"url"`
+	Headers   map[string]string `toml:"headers,omitempty"`
+	TimeoutMs int               `toml:"timeout_ms,omitempty"`
+	Retries   int               `toml:"retries,omitempty"`
+	BackoffMs int               `toml:"backoff_ms,omitempty"`
+}
+
+// Schedule is the action run by a schedule_action binding after DelayMs,
+// targeting the binding selector.
+type Schedule struct {
+	DelayMs int    `toml:"delay_ms"`
+	Action  Action `toml:"action"`
+	HSBK    *HSBK  `toml:"hsbk,omitempty"`
 }
 
 type HSBK struct {
@@ -150,13 +574,35 @@ func LoadConfig(userConfigPath string) (*Config, error) {
 }
 
 func newBaseConfig() *Config {
+	holdMs, fadeMs := defaultHoldMs, defaultFadeMs
 	return &Config{
 		General: General{TransitionMs: defaultTransitionMs},
 		Logging: Logging{Level: defaultLogLevel},
 		Tracking: Tracking{
-			FrameSkip:  defaultFrameSkip,
-			BufferSize: defaultBufferSize,
+			FrameSkip:    defaultFrameSkip,
+			BufferSize:   defaultBufferSize,
+			StableFrames: defaultStableFrames,
+		},
+		Occupancy: Occupancy{
+			IdleTimeoutMs: defaultIdleTimeoutMs,
+			FadeMs:        &fadeMs,
+			Action:        ActionPowerOff,
+			Selector:      Selector{Type: SelectorTypeAll},
 		},
+		Arming: Arming{
+			HoldMs:         &holdMs,
+			ArmedTimeoutMs: defaultArmedTimeoutMs,
+		},
+		Timers: Timers{MaxPending: defaultMaxPendingTimers},
+		Exec:   ExecLimit{MaxConcurrent: defaultMaxConcurrentExec},
+		MQTT: MQTT{
+			ClientID:     defaultMQTTClientID,
+			TopicPrefix:  defaultMQTTTopicPrefix,
+			MinBackoffMs: defaultMQTTMinBackoffMs,
+			MaxBackoffMs: defaultMQTTMaxBackoffMs,
+			Discovery:    Discovery{Prefix: defaultDiscoveryPrefix},
+		},
+		OSC: OSC{Port: defaultOSCPort},
 	}
 }
 
@@ -176,6 +622,12 @@ func writeConfigFile(cfg *Config, path string) error {
 	return nil
 }
 
+// ReadConfig reads the config file at the given path as is, without creating
+// it, merging the defaults or validating it.
+func ReadConfig(configPath string) (*Config, error) {
+	return readConfigFile(configPath)
+}
+
 func readConfigFile(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -223,6 +675,10 @@ func merge(base, user any) error {
 			if !uf.IsZero() {
 				bf.Set(uf)
 			}
+		case reflect.Bool:
+			if uf.Bool() {
+				bf.Set(uf)
+			}
 		case reflect.String:
 			if uf.Len() > 0 {
 				bf.Set(uf)
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -17,14 +18,60 @@ func TestLoadConfig(t *testing.T) {
 
 		handClosed = FingerPattern{0, 0, 0, 0, 0}
 		handOpen   = FingerPattern{1, 1, 1, 1, 1}
+		handPeace  = FingerPattern{0, 1, 1, 0, 0}
 
-		h0, h1    float64 = 240, 0
-		p0        float64 = 100
-		defaultMs         = 1
-		userCfg0          = &Config{
+		h0, h1        float64 = 240, 0
+		p0            float64 = 100
+		defaultMs             = 1
+		defaultHoldMs         = 1000
+		defaultFadeMs         = 30000
+		// Zero durations are kept rather than replaced by the defaults.
+		zeroMs   = 0
+		userCfg0 = &Config{
 			General:  General{TransitionMs: 10},
 			Logging:  Logging{Level: "info", File: "lifx-force.log"},
-			Tracking: Tracking{FrameSkip: 1, BufferSize: 8},
+			Tracking: Tracking{FrameSkip: 1, BufferSize: 8, Preview: true, StableFrames: 3, SuspendWhenPaused: true},
+			Occupancy: Occupancy{
+				Enabled:       true,
+				IdleTimeoutMs: 300000,
+				FadeMs:        &zeroMs,
+				Action:        ActionPowerSetColor,
+				HSBK:          &HSBK{Brightness: &h1},
+				Selector:      Selector{Type: SelectorTypeGroup, Value: "living room"},
+			},
+			Arming: Arming{
+				Enabled:        true,
+				Pattern:        &handPeace,
+				HoldMs:         &zeroMs,
+				ArmedTimeoutMs: 20000,
+				RearmOnAction:  true,
+				Indicator: &Indicator{
+					Selector: Selector{Type: SelectorTypeLabel, Value: "desk"},
+					ArmHSBK:  &HSBK{Hue: &h0},
+				},
+			},
+			Timers: Timers{MaxPending: 4, PersistFile: "timers.json"},
+			Exec:   ExecLimit{MaxConcurrent: 2},
+			API:    API{Listen: "127.0.0.1:8787", Dashboard: Dashboard{Enabled: true}},
+			MQTT: MQTT{
+				Broker:       "ssl://localhost:8883",
+				ClientID:     "desk",
+				Username:     "lifx",
+				Password:     "secret",
+				TopicPrefix:  "home/lifx-force",
+				QoS:          1,
+				MinBackoffMs: 500,
+				MaxBackoffMs: 30000,
+				TLS:          &MQTTTLS{CAFile: "ca.pem"},
+				Discovery:    Discovery{Enabled: true, Prefix: "ha"},
+			},
+			OSC:      OSC{Host: "192.168.1.20", Port: 8000},
+			Control:  Control{Enabled: true, Socket: "/tmp/lifx-force.sock"},
+			Feedback: Feedback{Enabled: true, Selector: &Selector{Type: SelectorTypeLabel, Value: "Desk"}, ErrorHSBK: &HSBK{Hue: &h0}},
+			Zones: []Zone{
+				{Name: "lamp", XMin: 0, XMax: 0.33, Selector: Selector{Type: SelectorTypeLabel, Value: "Lamp"}},
+				{Name: "ceiling", XMin: 0.66, XMax: 1, YMax: 0.5, Selector: Selector{Type: SelectorTypeGroup, Value: "Ceiling"}},
+			},
 			Bindings: []Binding{
 				{
 					Gesture:  GestureSwipeLeft,
@@ -80,6 +127,38 @@ func TestLoadConfig(t *testing.T) {
 					Action:   "power_off",
 					Selector: Selector{Type: SelectorTypeAll},
 				},
+				{
+					Pattern:  &handPeace,
+					Action:   "schedule_action",
+					Selector: Selector{Type: SelectorTypeGroup, Value: "bedroom"},
+					Schedule: &Schedule{DelayMs: 1800000, Action: "power_off"},
+				},
+				{
+					Presence: PresenceEnter,
+					Action:   "cancel_timers",
+				},
+				{
+					Gesture: GesturePullUp,
+					Action:  "exec",
+					Exec: &Exec{
+						Command:   "playerctl",
+						Args:      []string{"play-pause"},
+						TimeoutMs: 2000,
+						Env:       []string{"DBUS_SESSION_BUS_ADDRESS"},
+					},
+				},
+				{
+					Name:    "movie_mode",
+					Pattern: &handOpen,
+					Action:  "webhook",
+					Webhook: &Webhook{
+						URL:       "http://localhost:8123/api/webhook/movie",
+						Headers:   map[string]string{"Authorization": "Bearer token"},
+						TimeoutMs: 2000,
+						Retries:   2,
+						BackoffMs: 500,
+					},
+				},
 			},
 		}
 	)
@@ -97,7 +176,24 @@ func TestLoadConfig(t *testing.T) {
 			want: &Config{
 				General:  General{TransitionMs: defaultMs},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Occupancy: Occupancy{
+					IdleTimeoutMs: 600000,
+					FadeMs:        &defaultFadeMs,
+					Action:        ActionPowerOff,
+					Selector:      Selector{Type: SelectorTypeAll},
+				},
+				Arming: Arming{HoldMs: &defaultHoldMs, ArmedTimeoutMs: 10000},
+				Timers: Timers{MaxPending: 8},
+				Exec:   ExecLimit{MaxConcurrent: 4},
+				MQTT: MQTT{
+					ClientID:     "lifx-force",
+					TopicPrefix:  "lifx-force",
+					MinBackoffMs: 1000,
+					MaxBackoffMs: 60000,
+					Discovery:    Discovery{Prefix: "homeassistant"},
+				},
+				OSC: OSC{Port: 9000},
 			},
 		},
 		"with user config": {
@@ -128,3 +224,78 @@ func TestLoadConfig(t *testing.T) {
 	assert.Nil(t, got)
 	assert.FileExists(t, tempFilePathEditedInvalid)
 }
+
+func TestReadConfig(t *testing.T) {
+	var (
+		tempDir     = t.TempDir()
+		pathMissing = filepath.Join(tempDir, "config-missing.toml")
+		pathPartial = filepath.Join(tempDir, "config-partial.toml")
+		pathInvalid = filepath.Join(tempDir, "config-invalid.toml")
+		partialCfg  = &Config{Control: Control{Socket: "/tmp/lifx-force.sock"}, General: General{TransitionMs: -1}}
+	)
+	if err := writeConfigFile(partialCfg, pathPartial); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pathInvalid, []byte("not toml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		path    string
+		want    *Config
+		wantErr bool
+	}{
+		"missing file is not created": {
+			path:    pathMissing,
+			wantErr: true,
+		},
+		"file is read without defaults or validation": {
+			path: pathPartial,
+			want: partialCfg,
+		},
+		"invalid file": {
+			path:    pathInvalid,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ReadConfig(tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	assert.NoFileExists(t, pathMissing)
+}
+
+func TestBindingTriggerID(t *testing.T) {
+	var (
+		peace = FingerPattern{0, 1, 1, 0, 0}
+		three = 3
+	)
+
+	testCases := map[string]struct {
+		binding Binding
+		want    string
+	}{
+		"name":           {binding: Binding{Name: "movie mode!", Gesture: GestureSwipeUp}, want: "movie_mode_"},
+		"gesture":        {binding: Binding{Gesture: GestureSwipeUp}, want: "gesture_swipe_up"},
+		"pattern":        {binding: Binding{Pattern: &peace, Tolerance: 1}, want: "pattern_01100"},
+		"named count":    {binding: Binding{Name: "scene 3", Count: &three}, want: "scene_3"},
+		"unnamed count":  {binding: Binding{Count: &three}},
+		"fallback":       {binding: Binding{Fallback: true}},
+		"named fallback": {binding: Binding{Name: "other", Fallback: true}},
+		"presence":       {binding: Binding{Name: "away", Presence: PresenceLeave}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.binding.TriggerID())
+		})
+	}
+}
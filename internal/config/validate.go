@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 
 	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
 )
@@ -21,13 +24,129 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Occupancy.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Arming.Validate(); err != nil {
+		return err
+	}
+
+	if c.Timers.MaxPending < 0 {
+		return fmt.Errorf("timers.max_pending must be >= 0")
+	}
+
+	if c.Exec.MaxConcurrent < 0 {
+		return fmt.Errorf("exec.max_concurrent must be >= 0")
+	}
+
+	if err := c.API.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.MQTT.Validate(); err != nil {
+		return err
+	}
+
+	if c.OSC.Host != "" && (c.OSC.Port <= 0 || c.OSC.Port > 65535) {
+		return fmt.Errorf("osc.port must be between 1 and 65535")
+	}
+
+	if err := c.Feedback.Validate(); err != nil {
+		return fmt.Errorf("feedback: %w", err)
+	}
+
+	if err := validateZones(c.Zones); err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{})
 	for i := range c.Bindings {
 		b := &c.Bindings[i]
 		if err := b.Validate(); err != nil {
 			return fmt.Errorf("bindings[%d]: %w", i, err)
 		}
+		if b.Selector.Type == SelectorTypeZone && len(c.Zones) == 0 {
+			return fmt.Errorf("bindings[%d]: selector type zone requires zones", i)
+		}
+		if b.Name == "" {
+			continue
+		}
+		if _, ok := names[b.Name]; ok {
+			return fmt.Errorf("bindings[%d]: duplicate name %q", i, b.Name)
+		}
+		names[b.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// Warnings returns the issues of a valid config that do not prevent it from
+// running, such as pattern bindings that their tolerance makes indistinguishable.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	for i, a := range c.Bindings {
+		for j := i + 1; j < len(c.Bindings); j++ {
+			b := c.Bindings[j]
+			if a.Pattern == nil || b.Pattern == nil {
+				continue
+			}
+			// A pattern halfway between the two matches both at the same distance,
+			// in which case neither fires.
+			d := a.Pattern.Distance(*b.Pattern)
+			if d > 0 && d%2 == 0 && d/2 <= min(a.Tolerance, b.Tolerance) {
+				warnings = append(warnings, fmt.Sprintf("bindings[%d] and bindings[%d]: patterns %v and %v are indistinguishable with tolerance %d",
+					i, j, *a.Pattern, *b.Pattern, min(a.Tolerance, b.Tolerance)))
+			}
+		}
 	}
+	return warnings
+}
 
+func (a *API) Validate() error {
+	if a.Listen == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(a.Listen); err != nil {
+		return fmt.Errorf("api.listen must be a valid host:port")
+	}
+	return nil
+}
+
+func (m *MQTT) Validate() error {
+	if m.Broker == "" {
+		return nil
+	}
+	u, err := url.Parse(m.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("mqtt.broker must be a valid URL, e.g. tcp://localhost:1883")
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+	default:
+		return fmt.Errorf("mqtt.broker scheme must be one of tcp, mqtt, ssl, tls, mqtts, ws, wss")
+	}
+	if m.ClientID == "" {
+		return fmt.Errorf("mqtt.client_id is required")
+	}
+	if m.TopicPrefix == "" || strings.ContainsAny(m.TopicPrefix, "#+") {
+		return fmt.Errorf("mqtt.topic_prefix must be set and cannot contain wildcards")
+	}
+	if m.QoS < 0 || m.QoS > 2 {
+		return fmt.Errorf("mqtt.qos must be 0, 1 or 2")
+	}
+	if m.MinBackoffMs <= 0 {
+		return fmt.Errorf("mqtt.min_backoff_ms must be > 0")
+	}
+	if m.MaxBackoffMs < m.MinBackoffMs {
+		return fmt.Errorf("mqtt.max_backoff_ms must be >= mqtt.min_backoff_ms")
+	}
+	if m.TLS != nil && (m.TLS.CertFile == "") != (m.TLS.KeyFile == "") {
+		return fmt.Errorf("mqtt.tls: cert_file and key_file must be set together")
+	}
+	if m.Discovery.Enabled && (m.Discovery.Prefix == "" || strings.ContainsAny(m.Discovery.Prefix, "#+")) {
+		return fmt.Errorf("mqtt.discovery.prefix must be set and cannot contain wildcards")
+	}
 	return nil
 }
 
@@ -38,35 +157,343 @@ func (t *Tracking) Validate() error {
 	if t.BufferSize <= 0 {
 		return fmt.Errorf("tracking.buffer_size must be > 0")
 	}
+	if t.StableFrames <= 0 {
+		return fmt.Errorf("tracking.stable_frames must be > 0")
+	}
+	return nil
+}
+
+func (o *Occupancy) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.IdleTimeoutMs <= 0 {
+		return fmt.Errorf("occupancy.idle_timeout_ms must be > 0")
+	}
+	if o.FadeMs != nil && *o.FadeMs < 0 {
+		return fmt.Errorf("occupancy.fade_ms must be >= 0")
+	}
+	switch o.Action {
+	case "", ActionPowerOff:
+	case ActionPowerSetColor:
+		if err := ValidateActionAndArgs(o.Action, o.HSBK); err != nil {
+			return fmt.Errorf("occupancy: %w", err)
+		}
+	default:
+		return fmt.Errorf("occupancy.action must be one of %s, %s", ActionPowerOff, ActionPowerSetColor)
+	}
+	if err := o.Selector.Validate(); err != nil {
+		return fmt.Errorf("occupancy: %w", err)
+	}
+	return nil
+}
+
+func (a *Arming) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	switch {
+	case a.Gesture != "" && a.Pattern != nil:
+		return fmt.Errorf("arming: only one of gesture or pattern is allowed")
+	case a.Gesture != "":
+		if _, ok := supportedGestures[a.Gesture]; !ok {
+			return fmt.Errorf("arming: invalid gesture: %s", a.Gesture)
+		}
+	case a.Pattern != nil:
+		if err := a.Pattern.Validate(); err != nil {
+			return fmt.Errorf("arming: %w", err)
+		}
+	default:
+		return fmt.Errorf("arming: one of gesture or pattern is required")
+	}
+	if a.HoldMs != nil && *a.HoldMs < 0 {
+		return fmt.Errorf("arming.hold_ms must be >= 0")
+	}
+	if a.ArmedTimeoutMs <= 0 {
+		return fmt.Errorf("arming.armed_timeout_ms must be > 0")
+	}
+	if err := a.Indicator.Validate(); err != nil {
+		return fmt.Errorf("arming.indicator: %w", err)
+	}
+	return nil
+}
+
+func (i *Indicator) Validate() error {
+	if i == nil {
+		return nil
+	}
+	if err := i.Selector.Validate(); err != nil {
+		return err
+	}
+	if err := i.ArmHSBK.Validate(); err != nil {
+		return err
+	}
+	return i.DisarmHSBK.Validate()
+}
+
+func (f *Feedback) Validate() error {
+	if f == nil || !f.Enabled {
+		return nil
+	}
+	if f.Selector != nil {
+		if err := f.Selector.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := f.HSBK.Validate(); err != nil {
+		return err
+	}
+	return f.ErrorHSBK.Validate()
+}
+
+// validateZoneSelector checks that the binding is triggered by a single hand,
+// whose position resolves the zone, when its action runs.
+func (b *Binding) validateZoneSelector() error {
+	switch {
+	case b.Presence != "" || b.Gesture.IsCompound():
+		return fmt.Errorf("selector type zone requires a single hand gesture or a pattern")
+	case b.Action == ActionScheduleAction:
+		return fmt.Errorf("selector type zone is not supported by action %s", ActionScheduleAction)
+	case b.If != nil:
+		return fmt.Errorf("selector type zone is not supported with if conditions")
+	}
+	return nil
+}
+
+// validateZones checks that zones are named, within the frame and do not overlap.
+func validateZones(zones []Zone) error {
+	names := make(map[string]struct{}, len(zones))
+	for i := range zones {
+		z := &zones[i]
+		if z.Name == "" {
+			return fmt.Errorf("zones[%d]: name is required", i)
+		}
+		if _, ok := names[z.Name]; ok {
+			return fmt.Errorf("zones[%d]: duplicate name %q", i, z.Name)
+		}
+		names[z.Name] = struct{}{}
+
+		yMin, yMax := z.YRange()
+		if z.XMin < 0 || z.XMax > 1 || z.XMin >= z.XMax || yMin < 0 || yMax > 1 || yMin >= yMax {
+			return fmt.Errorf("zones[%d]: bounds must be within 0-1 with min < max", i)
+		}
+		if err := z.Selector.Validate(); err != nil {
+			return fmt.Errorf("zones[%d]: %w", i, err)
+		}
+		for j := range i {
+			if z.Overlaps(&zones[j]) {
+				return fmt.Errorf("zones[%d]: %q overlaps %q", i, z.Name, zones[j].Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *FingerPattern) Validate() error {
+	for _, f := range p {
+		if f != 0 && f != 1 {
+			return fmt.Errorf("pattern should only contain 0s & 1s")
+		}
+	}
 	return nil
 }
 
 func (b *Binding) Validate() error {
-	if b.Gesture == "" && b.Pattern == nil {
-		return fmt.Errorf("one of gesture or pattern is required")
+	if b.Fallback && (b.Gesture != "" || b.Pattern != nil || b.Presence != "" || b.Count != nil) {
+		return fmt.Errorf("fallback cannot be combined with gesture, pattern, presence or count")
 	}
-	if b.Gesture != "" {
+	switch {
+	case b.Gesture != "":
 		if _, ok := supportedGestures[b.Gesture]; !ok {
 			return fmt.Errorf("invalid gesture: %s", b.Gesture)
 		}
-	} else {
-		for _, p := range *b.Pattern {
-			if p != 0 && p != 1 {
-				return fmt.Errorf("pattern should only contain 0s & 1s")
-			}
+	case b.Pattern != nil:
+		if err := b.Pattern.Validate(); err != nil {
+			return err
 		}
+	case b.Presence != "":
+		if b.Presence != PresenceEnter && b.Presence != PresenceLeave {
+			return fmt.Errorf("invalid presence: %s", b.Presence)
+		}
+	case b.Count != nil:
+		if *b.Count < 0 || *b.Count > len(FingerPattern{}) {
+			return fmt.Errorf("count must be between 0 and %d", len(FingerPattern{}))
+		}
+	case b.Fallback:
+	default:
+		return fmt.Errorf("one of gesture, pattern, presence, count or fallback is required")
 	}
-
-	if err := b.Selector.Validate(); err != nil {
+	switch {
+	case b.Hand == "":
+	case b.Count == nil || b.Gesture != "" || b.Pattern != nil || b.Presence != "":
+		return fmt.Errorf("hand is only supported with count")
+	case b.Hand != "left" && b.Hand != "right":
+		return fmt.Errorf("hand must be one of left, right")
+	}
+	switch {
+	case b.Tolerance == 0:
+	case b.Pattern == nil:
+		return fmt.Errorf("tolerance is only supported with pattern")
+	case b.Tolerance < 0 || b.Tolerance >= len(FingerPattern{}):
+		return fmt.Errorf("tolerance must be between 0 and %d", len(FingerPattern{})-1)
+	}
+	if b.AfterMs < 0 {
+		return fmt.Errorf("after_ms must be >= 0")
+	}
+	if b.TransitionMs < 0 {
+		return fmt.Errorf("transition_ms must be >= 0")
+	}
+	if err := b.When.Validate(); err != nil {
 		return err
 	}
-	if err := ValidateActionAndArgs(b.Action, b.HSBK); err != nil {
+
+	switch {
+	case b.Selector.Type == SelectorTypeZone:
+		if err := b.validateZoneSelector(); err != nil {
+			return err
+		}
+	case b.Action.RequiresSelector() || b.Selector.Type != "":
+		if err := b.Selector.Validate(); err != nil {
+			return err
+		}
+	}
+	switch b.Action {
+	case ActionScheduleAction:
+		if err := b.Schedule.Validate(); err != nil {
+			return err
+		}
+	case ActionCancelTimers, ActionTogglePause:
+	case ActionExec:
+		if err := b.Exec.Validate(); err != nil {
+			return err
+		}
+	case ActionWebhook:
+		if err := b.Webhook.Validate(); err != nil {
+			return err
+		}
+	case ActionDial:
+		if b.Pattern == nil && b.Count == nil {
+			return fmt.Errorf("action %s requires a pattern or count", ActionDial)
+		}
+		if err := b.Dial.Validate(); err != nil {
+			return err
+		}
+	default:
+		if err := ValidateActionAndArgs(b.Action, b.HSBK); err != nil {
+			return err
+		}
+	}
+	if err := b.If.Validate(); err != nil {
 		return err
 	}
+	if b.If != nil && b.Selector.Type == "" {
+		// The condition is checked against the selected devices, without a
+		// selector it could never be met.
+		return fmt.Errorf("if requires a selector")
+	}
+	if err := b.Feedback.Validate(); err != nil {
+		return fmt.Errorf("feedback: %w", err)
+	}
+	if b.Else != nil {
+		if b.If == nil {
+			return fmt.Errorf("else requires an if condition")
+		}
+		if err := ValidateActionAndArgs(b.Else.Action, b.Else.HSBK); err != nil {
+			return fmt.Errorf("else: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Schedule) Validate() error {
+	if s == nil {
+		return fmt.Errorf("schedule must be set for action %s", ActionScheduleAction)
+	}
+	if s.DelayMs <= 0 {
+		return fmt.Errorf("schedule.delay_ms must be > 0")
+	}
+	if err := ValidateActionAndArgs(s.Action, s.HSBK); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	return nil
+}
+
+func (e *Exec) Validate() error {
+	if e == nil {
+		return fmt.Errorf("exec must be set for action %s", ActionExec)
+	}
+	if e.Command == "" {
+		return fmt.Errorf("exec.command is required")
+	}
+	if e.TimeoutMs < 0 {
+		return fmt.Errorf("exec.timeout_ms must be >= 0")
+	}
+	return nil
+}
+
+func (w *Webhook) Validate() error {
+	if w == nil {
+		return fmt.Errorf("webhook must be set for action %s", ActionWebhook)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook.url must be a valid http or https URL")
+	}
+	if w.TimeoutMs < 0 {
+		return fmt.Errorf("webhook.timeout_ms must be >= 0")
+	}
+	if w.Retries < 0 {
+		return fmt.Errorf("webhook.retries must be >= 0")
+	}
+	if w.BackoffMs < 0 {
+		return fmt.Errorf("webhook.backoff_ms must be >= 0")
+	}
+	return nil
+}
 
+func (d *Dial) Validate() error {
+	if d == nil {
+		return fmt.Errorf("dial must be set for action %s", ActionDial)
+	}
+	switch d.Source {
+	case DialSourceWristY, DialSourcePalmX, DialSourcePinch:
+	default:
+		return fmt.Errorf("dial.source must be one of wrist_y, palm_x, pinch")
+	}
+	switch d.Property {
+	case DialPropertyBrightness, DialPropertyHue, DialPropertyKelvin:
+	default:
+		return fmt.Errorf("dial.property must be one of brightness, hue, kelvin")
+	}
+	switch d.Curve {
+	case "", DialCurveLinear, DialCurveEaseIn, DialCurveEaseOut:
+	default:
+		return fmt.Errorf("dial.curve must be one of linear, ease_in, ease_out")
+	}
+	minValue, maxValue := d.Property.Range()
+	outMin, outMax := d.OutputRange()
+	if min(outMin, outMax) < minValue || max(outMin, outMax) > maxValue {
+		return fmt.Errorf("dial output range must be within %v-%v for %s", minValue, maxValue, d.Property)
+	}
+	if d.Deadband < 0 {
+		return fmt.Errorf("dial.deadband must be >= 0")
+	}
+	if d.RateLimitMs < 0 {
+		return fmt.Errorf("dial.rate_limit_ms must be >= 0")
+	}
 	return nil
 }
 
+// RequiresSelector returns whether the action targets devices.
+func (a Action) RequiresSelector() bool {
+	switch a {
+	case ActionCancelTimers, ActionExec, ActionWebhook, ActionTogglePause:
+		return false
+	}
+	return true
+}
+
 func (s *Selector) Validate() error {
 	switch s.Type {
 	case SelectorTypeAll:
@@ -121,7 +548,7 @@ func ValidateActionAndArgs(a Action, hsbk *HSBK) error {
 	var hsbkRequired bool
 	switch a {
 	case ActionPowerOn, ActionPowerOff:
-	case ActionPowerSetColor:
+	case ActionPowerSetColor, ActionFlash:
 		hsbkRequired = true
 	case "":
 		return fmt.Errorf("action is required")
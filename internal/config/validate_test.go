@@ -9,9 +9,12 @@ import (
 func TestValidate(t *testing.T) {
 	var (
 		h, s           float64 = 180, 100
+		invalidHue     float64 = 400
 		hsbk0                  = &HSBK{Hue: &h, Saturation: &s}
 		invalidPattern         = FingerPattern{1, 2, 3, 4, 5}
 		handClosed             = FingerPattern{0, 0, 0, 0, 0}
+		handOpen               = FingerPattern{1, 1, 1, 1, 1}
+		two, six               = 2, 6
 	)
 
 	testCases := map[string]struct {
@@ -47,11 +50,597 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: "tracking.buffer_size must be > 0",
 		},
+		"invalid tracking: stable_frames": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 0},
+			},
+			wantErr: "tracking.stable_frames must be > 0",
+		},
+		"invalid occupancy: idle_timeout_ms": {
+			cfg: &Config{
+				General:   General{TransitionMs: 1},
+				Logging:   Logging{Level: "info"},
+				Tracking:  Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Occupancy: Occupancy{Enabled: true},
+			},
+			wantErr: "occupancy.idle_timeout_ms must be > 0",
+		},
+		"invalid occupancy: selector": {
+			cfg: &Config{
+				General:   General{TransitionMs: 1},
+				Logging:   Logging{Level: "info"},
+				Tracking:  Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Occupancy: Occupancy{Enabled: true, IdleTimeoutMs: 1000, Selector: Selector{Type: "group"}},
+			},
+			wantErr: `occupancy: missing selector value for type "group"`,
+		},
+		"invalid occupancy: action": {
+			cfg: &Config{
+				General:   General{TransitionMs: 1},
+				Logging:   Logging{Level: "info"},
+				Tracking:  Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Occupancy: Occupancy{Enabled: true, IdleTimeoutMs: 1000, Action: ActionExec},
+			},
+			wantErr: "occupancy.action must be one of power_off, set_color",
+		},
+		"invalid occupancy: hsbk": {
+			cfg: &Config{
+				General:   General{TransitionMs: 1},
+				Logging:   Logging{Level: "info"},
+				Tracking:  Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Occupancy: Occupancy{Enabled: true, IdleTimeoutMs: 1000, Action: ActionPowerSetColor},
+			},
+			wantErr: "occupancy: hsbk must be set for action set_color",
+		},
+		"invalid arming: missing wake trigger": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Arming:   Arming{Enabled: true},
+			},
+			wantErr: "arming: one of gesture or pattern is required",
+		},
+		"invalid arming: armed_timeout_ms": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Arming:   Arming{Enabled: true, Gesture: GesturePullUp},
+			},
+			wantErr: "arming.armed_timeout_ms must be > 0",
+		},
+		"invalid arming: indicator": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Arming:   Arming{Enabled: true, Pattern: &handClosed, ArmedTimeoutMs: 1000, Indicator: &Indicator{}},
+			},
+			wantErr: `arming.indicator: unknown selector type ""`,
+		},
+		"invalid binding: missing trigger": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: one of gesture, pattern, presence, count or fallback is required",
+		},
+		"invalid count binding: count": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Count: &six, Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: count must be between 0 and 5",
+		},
+		"invalid count binding: hand without count": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Pattern: &handClosed, Hand: "left", Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: hand is only supported with count",
+		},
+		"invalid count binding: hand": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Count: &two, Hand: "both", Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: hand must be one of left, right",
+		},
+		"invalid pattern binding: tolerance without pattern": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeUp, Tolerance: 1, Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: tolerance is only supported with pattern",
+		},
+		"invalid pattern binding: tolerance": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Pattern: &handClosed, Tolerance: 5, Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: tolerance must be between 0 and 4",
+		},
+		"invalid fallback binding: combined with gesture": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeUp, Fallback: true, Action: ActionPowerOn, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: fallback cannot be combined with gesture, pattern, presence or count",
+		},
+		"invalid fallback binding: flash without hsbk": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Fallback: true, Action: ActionFlash, Selector: Selector{Type: "all"}},
+				},
+			},
+			wantErr: "bindings[0]: hsbk must be set for action flash",
+		},
+		"invalid presence binding: presence": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Presence: "arrive"},
+				},
+			},
+			wantErr: "bindings[0]: invalid presence: arrive",
+		},
+		"invalid presence binding: after_ms": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Presence: PresenceLeave, AfterMs: -1},
+				},
+			},
+			wantErr: "bindings[0]: after_ms must be >= 0",
+		},
+		"invalid binding: when day": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, When: &When{Days: []string{"funday"}}},
+				},
+			},
+			wantErr: `bindings[0]: when: invalid day "funday", must be one of mon, tue, wed, thu, fri, sat, sun`,
+		},
+		"invalid binding: when time": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, When: &When{From: "25:00"}},
+				},
+			},
+			wantErr: `bindings[0]: when.from: invalid time "25:00", must be HH:MM`,
+		},
+		"invalid binding: if power": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerOn, If: &Condition{Power: "dim"}},
+				},
+			},
+			wantErr: "bindings[0]: if.power must be one of on, off",
+		},
+		"invalid binding: if brightness": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerOn, If: &Condition{Brightness: &Range{Min: &s, Max: &h}}},
+				},
+			},
+			wantErr: "bindings[0]: if.brightness: invalid value [180], must be 0-100",
+		},
+		"invalid binding: if without selector": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionWebhook, Webhook: &Webhook{URL: "http://localhost:8123/api/webhook/lights"}, If: &Condition{Power: "on"}},
+				},
+			},
+			wantErr: "bindings[0]: if requires a selector",
+		},
+		"invalid binding: else without if": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerOn, Else: &Else{Action: ActionPowerOff}},
+				},
+			},
+			wantErr: "bindings[0]: else requires an if condition",
+		},
+		"invalid binding: else action": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerOn, If: &Condition{Power: "off"}, Else: &Else{Action: ActionPowerSetColor}},
+				},
+			},
+			wantErr: "bindings[0]: else: hsbk must be set for action set_color",
+		},
+		"invalid binding: schedule required": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionScheduleAction},
+				},
+			},
+			wantErr: "bindings[0]: schedule must be set for action schedule_action",
+		},
+		"invalid binding: schedule delay_ms": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionScheduleAction, Schedule: &Schedule{Action: ActionPowerOff}},
+				},
+			},
+			wantErr: "bindings[0]: schedule.delay_ms must be > 0",
+		},
+		"invalid binding: nested schedule action": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionScheduleAction, Schedule: &Schedule{DelayMs: 1, Action: ActionCancelTimers}},
+				},
+			},
+			wantErr: "bindings[0]: schedule: invalid action: cancel_timers",
+		},
+		"invalid timers: max_pending": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Timers:   Timers{MaxPending: -1},
+			},
+			wantErr: "timers.max_pending must be >= 0",
+		},
+		"invalid binding: transition_ms": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, TransitionMs: -1},
+				},
+			},
+			wantErr: "bindings[0]: transition_ms must be >= 0",
+		},
+		"invalid binding: exec required": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionExec},
+				},
+			},
+			wantErr: "bindings[0]: exec must be set for action exec",
+		},
+		"invalid binding: exec command": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionExec, Exec: &Exec{Args: []string{"{gesture}"}}},
+				},
+			},
+			wantErr: "bindings[0]: exec.command is required",
+		},
+		"invalid api listen": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				API:      API{Listen: "8787"},
+			},
+			wantErr: "api.listen must be a valid host:port",
+		},
+		"invalid mqtt broker": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force", MinBackoffMs: 1000, MaxBackoffMs: 60000},
+			},
+			wantErr: "mqtt.broker must be a valid URL, e.g. tcp://localhost:1883",
+		},
+		"invalid mqtt broker scheme": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "http://localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force", MinBackoffMs: 1000, MaxBackoffMs: 60000},
+			},
+			wantErr: "mqtt.broker scheme must be one of tcp, mqtt, ssl, tls, mqtts, ws, wss",
+		},
+		"invalid mqtt topic prefix": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "tcp://localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force/#", MinBackoffMs: 1000, MaxBackoffMs: 60000},
+			},
+			wantErr: "mqtt.topic_prefix must be set and cannot contain wildcards",
+		},
+		"invalid mqtt qos": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "tcp://localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force", QoS: 3, MinBackoffMs: 1000, MaxBackoffMs: 60000},
+			},
+			wantErr: "mqtt.qos must be 0, 1 or 2",
+		},
+		"invalid mqtt backoff": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "tcp://localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force", MinBackoffMs: 1000, MaxBackoffMs: 500},
+			},
+			wantErr: "mqtt.max_backoff_ms must be >= mqtt.min_backoff_ms",
+		},
+		"invalid mqtt tls": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT:     MQTT{Broker: "ssl://localhost:8883", ClientID: "lifx-force", TopicPrefix: "lifx-force", MinBackoffMs: 1000, MaxBackoffMs: 60000, TLS: &MQTTTLS{CertFile: "client.pem"}},
+			},
+			wantErr: "mqtt.tls: cert_file and key_file must be set together",
+		},
+		"invalid mqtt discovery prefix": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				MQTT: MQTT{
+					Broker: "tcp://localhost:1883", ClientID: "lifx-force", TopicPrefix: "lifx-force", MinBackoffMs: 1000, MaxBackoffMs: 60000,
+					Discovery: Discovery{Enabled: true},
+				},
+			},
+			wantErr: "mqtt.discovery.prefix must be set and cannot contain wildcards",
+		},
+		"invalid osc port": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				OSC:      OSC{Host: "localhost", Port: 70000},
+			},
+			wantErr: "osc.port must be between 1 and 65535",
+		},
+		"invalid feedback: selector": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Feedback: Feedback{Enabled: true, Selector: &Selector{Type: SelectorTypeLabel}},
+			},
+			wantErr: "feedback: missing selector value for type \"label\"",
+		},
+		"invalid binding: feedback hsbk": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionPowerOff, Selector: Selector{Type: SelectorTypeAll}, Feedback: &Feedback{Enabled: true, ErrorHSBK: &HSBK{Hue: &invalidHue}}},
+				},
+			},
+			wantErr: "bindings[0]: feedback: invalid value for hue [400], must be 0-360",
+		},
+		"invalid binding: dial requires pattern": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionDial, Selector: Selector{Type: SelectorTypeAll}, Dial: &Dial{Source: DialSourcePinch, Property: DialPropertyBrightness}},
+				},
+			},
+			wantErr: "bindings[0]: action dial requires a pattern or count",
+		},
+		"invalid binding: dial required": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Pattern: &handClosed, Action: ActionDial, Selector: Selector{Type: SelectorTypeAll}},
+				},
+			},
+			wantErr: "bindings[0]: dial must be set for action dial",
+		},
+		"invalid binding: dial source": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Pattern: &handClosed, Action: ActionDial, Selector: Selector{Type: SelectorTypeAll}, Dial: &Dial{Source: "elbow", Property: DialPropertyBrightness}},
+				},
+			},
+			wantErr: "bindings[0]: dial.source must be one of wrist_y, palm_x, pinch",
+		},
+		"invalid binding: dial output range": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Pattern: &handClosed, Action: ActionDial, Selector: Selector{Type: SelectorTypeAll}, Dial: &Dial{Source: DialSourceWristY, Property: DialPropertyKelvin, OutputMin: 1000, OutputMax: 6500}},
+				},
+			},
+			wantErr: "bindings[0]: dial output range must be within 1500-9000 for kelvin",
+		},
+		"invalid zones: overlap": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Zones: []Zone{
+					{Name: "lamp", XMin: 0, XMax: 0.5, Selector: Selector{Type: SelectorTypeAll}},
+					{Name: "ceiling", XMin: 0.4, XMax: 1, YMin: 0.5, YMax: 1, Selector: Selector{Type: SelectorTypeAll}},
+				},
+			},
+			wantErr: "zones[1]: \"ceiling\" overlaps \"lamp\"",
+		},
+		"invalid zones: bounds": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Zones:    []Zone{{Name: "lamp", XMin: 0.5, XMax: 0.2, Selector: Selector{Type: SelectorTypeAll}}},
+			},
+			wantErr: "zones[0]: bounds must be within 0-1 with min < max",
+		},
+		"invalid zones: duplicate name": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Zones: []Zone{
+					{Name: "lamp", XMin: 0, XMax: 0.5, Selector: Selector{Type: SelectorTypeAll}},
+					{Name: "lamp", XMin: 0.5, XMax: 1, Selector: Selector{Type: SelectorTypeAll}},
+				},
+			},
+			wantErr: "zones[1]: duplicate name \"lamp\"",
+		},
+		"invalid zones: selector": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Zones:    []Zone{{Name: "lamp", XMin: 0, XMax: 0.5, Selector: Selector{Type: SelectorTypeZone}}},
+			},
+			wantErr: "zones[0]: unknown selector type \"zone\"",
+		},
+		"invalid binding: zone selector without zones": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{{Gesture: GestureSwipeLeft, Action: ActionPowerOff, Selector: Selector{Type: SelectorTypeZone}}},
+			},
+			wantErr: "bindings[0]: selector type zone requires zones",
+		},
+		"invalid binding: zone selector with compound gesture": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{{Gesture: GestureExpand, Action: ActionPowerOff, Selector: Selector{Type: SelectorTypeZone}}},
+			},
+			wantErr: "bindings[0]: selector type zone requires a single hand gesture or a pattern",
+		},
+		"invalid bindings: duplicate name": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Name: "off", Gesture: GestureSwipeLeft, Action: ActionPowerOff, Selector: Selector{Type: SelectorTypeAll}},
+					{Name: "off", Gesture: GestureSwipeRight, Action: ActionPowerOff, Selector: Selector{Type: SelectorTypeAll}},
+				},
+			},
+			wantErr: "bindings[1]: duplicate name \"off\"",
+		},
+		"invalid binding: webhook required": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionWebhook},
+				},
+			},
+			wantErr: "bindings[0]: webhook must be set for action webhook",
+		},
+		"invalid binding: webhook url": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionWebhook, Webhook: &Webhook{URL: "ftp://example.com"}},
+				},
+			},
+			wantErr: "bindings[0]: webhook.url must be a valid http or https URL",
+		},
+		"invalid binding: webhook retries": {
+			cfg: &Config{
+				General:  General{TransitionMs: 1},
+				Logging:  Logging{Level: "info"},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+				Bindings: []Binding{
+					{Gesture: GestureSwipeLeft, Action: ActionWebhook, Webhook: &Webhook{URL: "http://localhost", Retries: -1}},
+				},
+			},
+			wantErr: "bindings[0]: webhook.retries must be >= 0",
+		},
 		"invalid gesture binding: gesture": {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: "swoop"},
 				},
@@ -62,7 +651,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "serial"}},
 				},
@@ -73,7 +662,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}},
 				},
@@ -84,7 +673,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: "Unknown"},
 				},
@@ -95,7 +684,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerSetColor},
 				},
@@ -106,7 +695,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerSetColor, HSBK: &HSBK{}},
 				},
@@ -117,7 +706,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &invalidPattern},
 				},
@@ -128,7 +717,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &handClosed, Selector: Selector{Type: "serial"}},
 				},
@@ -139,7 +728,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &handClosed, Selector: Selector{Type: "all"}},
 				},
@@ -150,7 +739,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &handClosed, Selector: Selector{Type: "all"}, Action: "Unknown"},
 				},
@@ -161,7 +750,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &handClosed, Selector: Selector{Type: "all"}, Action: ActionPowerSetColor},
 				},
@@ -172,7 +761,7 @@ func TestValidate(t *testing.T) {
 			cfg: &Config{
 				General:  General{TransitionMs: 1},
 				Logging:  Logging{Level: "info"},
-				Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+				Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
 				Bindings: []Binding{
 					{Pattern: &handClosed, Selector: Selector{Type: "all"}, Action: ActionPowerSetColor, HSBK: &HSBK{}},
 				},
@@ -190,11 +779,65 @@ func TestValidate(t *testing.T) {
 	cfg0 := &Config{
 		General:  General{TransitionMs: 1},
 		Logging:  Logging{Level: "info"},
-		Tracking: Tracking{FrameSkip: 1, BufferSize: 5},
+		Tracking: Tracking{FrameSkip: 1, BufferSize: 5, StableFrames: 1},
+		Zones: []Zone{
+			{Name: "lamp", XMax: 0.5, Selector: Selector{Type: SelectorTypeLabel, Value: "Lamp"}},
+			{Name: "ceiling", XMin: 0.5, XMax: 1, Selector: Selector{Type: SelectorTypeGroup, Value: "Ceiling"}},
+		},
 		Bindings: []Binding{
 			{Gesture: GestureSwipeLeft, Selector: Selector{Type: "all"}, Action: ActionPowerOff},
 			{Pattern: &handClosed, Selector: Selector{Type: "all"}, Action: ActionPowerSetColor, HSBK: hsbk0},
+			{Presence: PresenceLeave, AfterMs: 600000, Selector: Selector{Type: "all"}, Action: ActionPowerOff},
+			{Presence: PresenceEnter, Action: ActionCancelTimers},
+			{Gesture: GestureSwipeUp, Action: ActionExec, Exec: &Exec{Command: "loginctl", Args: []string{"lock-session"}}},
+			{Name: "notify", Gesture: GestureSwipeDown, Action: ActionWebhook, Webhook: &Webhook{URL: "https://example.com/hook"}},
+			{Gesture: GestureExpand, Action: ActionTogglePause},
+			{Gesture: GestureSwipeDown, Selector: Selector{Type: SelectorTypeZone}, Action: ActionPowerOff},
+			{Count: &two, Hand: "right", Selector: Selector{Type: "all"}, Action: ActionPowerOn},
+			{Pattern: &handOpen, Tolerance: 1, Selector: Selector{Type: "all"}, Action: ActionPowerOn},
+			{Fallback: true, Selector: Selector{Type: "all"}, Action: ActionFlash, HSBK: hsbk0},
+			{Pattern: &handClosed, Action: ActionDial, Selector: Selector{Type: "all"}, Dial: &Dial{Source: DialSourcePinch, Property: DialPropertyBrightness, Curve: DialCurveEaseIn, Deadband: 1}},
 		},
 	}
 	assert.NoError(t, cfg0.Validate())
 }
+
+func TestWarnings(t *testing.T) {
+	var (
+		handOpen  = FingerPattern{1, 1, 1, 1, 1}
+		handPeace = FingerPattern{0, 1, 1, 0, 0}
+		handRock  = FingerPattern{0, 1, 0, 0, 1}
+		handThree = FingerPattern{0, 1, 1, 1, 0}
+	)
+
+	testCases := map[string]struct {
+		bindings     []Binding
+		wantWarnings []string
+	}{
+		"no tolerance": {
+			bindings: []Binding{{Pattern: &handPeace}, {Pattern: &handRock}},
+		},
+		"tolerance on one binding only": {
+			bindings: []Binding{{Pattern: &handPeace, Tolerance: 1}, {Pattern: &handRock}},
+		},
+		"odd distance is always distinguishable": {
+			bindings: []Binding{{Pattern: &handPeace, Tolerance: 2}, {Pattern: &handThree, Tolerance: 2}},
+		},
+		"same pattern": {
+			bindings: []Binding{{Pattern: &handPeace, Tolerance: 1}, {Pattern: &handPeace, Tolerance: 1}},
+		},
+		"indistinguishable patterns": {
+			bindings: []Binding{{Gesture: GestureSwipeUp}, {Pattern: &handOpen, Tolerance: 1}, {Pattern: &handPeace, Tolerance: 1}, {Pattern: &handRock, Tolerance: 1}},
+			wantWarnings: []string{
+				"bindings[2] and bindings[3]: patterns 01100 and 01001 are indistinguishable with tolerance 1",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{Bindings: tc.bindings}
+			assert.Equal(t, tc.wantWarnings, cfg.Warnings())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// When restricts a binding to a time window. From and To are times of day
+// in 24h format (HH:MM), where a window ending before it starts crosses midnight.
+// An empty From defaults to 00:00 and an empty To to the end of the day.
+// Days restricts the window to the given weekdays (mon-sun), matched against
+// the day the window starts on.
+type When struct {
+	Days []string `toml:"days,omitempty"`
+	From string   `toml:"from,omitempty"`
+	To   string   `toml:"to,omitempty"`
+}
+
+func (w *When) Validate() error {
+	if w == nil {
+		return nil
+	}
+	for _, d := range w.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("when: invalid day %q, must be one of mon, tue, wed, thu, fri, sat, sun", d)
+		}
+	}
+	if _, err := parseTimeOfDay(w.From, 0); err != nil {
+		return fmt.Errorf("when.from: %w", err)
+	}
+	if _, err := parseTimeOfDay(w.To, 24*time.Hour); err != nil {
+		return fmt.Errorf("when.to: %w", err)
+	}
+	return nil
+}
+
+// Active returns whether the given time falls within the window.
+// A nil When is always active.
+func (w *When) Active(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	from, _ := parseTimeOfDay(w.From, 0)
+	to, _ := parseTimeOfDay(w.To, 24*time.Hour)
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	day := t.Weekday()
+
+	switch {
+	case from == to:
+	case from < to:
+		if now < from || now >= to {
+			return false
+		}
+	case now >= from:
+	case now < to:
+		// The window crossing midnight started the previous day.
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return w.activeOn(day)
+}
+
+func (w *When) activeOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimeOfDay parses a HH:MM time into the duration since midnight,
+// returning def when the value is empty.
+func parseTimeOfDay(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
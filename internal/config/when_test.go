@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhenActive(t *testing.T) {
+	var (
+		// 2025-01-03 is a Friday.
+		friday = func(h, m int) time.Time { return time.Date(2025, 1, 3, h, m, 0, 0, time.Local) }
+		sat    = func(h, m int) time.Time { return time.Date(2025, 1, 4, h, m, 0, 0, time.Local) }
+	)
+
+	testCases := map[string]struct {
+		when *When
+		t    time.Time
+		want bool
+	}{
+		"nil is always active": {
+			when: nil,
+			t:    friday(3, 0),
+			want: true,
+		},
+		"empty is always active": {
+			when: &When{},
+			t:    friday(3, 0),
+			want: true,
+		},
+		"within daytime range": {
+			when: &When{From: "07:00", To: "22:00"},
+			t:    friday(7, 0),
+			want: true,
+		},
+		"end of range is excluded": {
+			when: &When{From: "07:00", To: "22:00"},
+			t:    friday(22, 0),
+			want: false,
+		},
+		"only from is set": {
+			when: &When{From: "22:00"},
+			t:    friday(23, 59),
+			want: true,
+		},
+		"only to is set": {
+			when: &When{To: "06:00"},
+			t:    friday(6, 30),
+			want: false,
+		},
+		"crossing midnight before midnight": {
+			when: &When{From: "22:00", To: "06:00"},
+			t:    friday(23, 0),
+			want: true,
+		},
+		"crossing midnight after midnight": {
+			when: &When{From: "22:00", To: "06:00"},
+			t:    sat(5, 59),
+			want: true,
+		},
+		"crossing midnight outside range": {
+			when: &When{From: "22:00", To: "06:00"},
+			t:    friday(12, 0),
+			want: false,
+		},
+		"matching day": {
+			when: &When{Days: []string{"mon", "fri"}},
+			t:    friday(12, 0),
+			want: true,
+		},
+		"not matching day": {
+			when: &When{Days: []string{"sat", "sun"}},
+			t:    friday(12, 0),
+			want: false,
+		},
+		"crossing midnight matches the start day": {
+			when: &When{Days: []string{"fri"}, From: "22:00", To: "06:00"},
+			t:    sat(1, 0),
+			want: true,
+		},
+		"crossing midnight does not match the end day": {
+			when: &When{Days: []string{"sat"}, From: "22:00", To: "06:00"},
+			t:    sat(1, 0),
+			want: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.when.Active(tc.t))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package consumer
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+// handleArming tracks the wake trigger and returns whether gesture and
+// pattern bindings may be handled for the given hands.
+// Events containing the wake trigger are consumed by arming.
+func (c *Consumer) handleArming(hs map[label]Hand) bool {
+	arming := &c.cfg.Arming
+	if !arming.Enabled {
+		return true
+	}
+
+	now := c.now()
+	if !c.matchWake(hs) {
+		c.wakeSince = time.Time{}
+		if !c.isArmed() {
+			c.logger.Debug("disarmed, ignoring event")
+		}
+		return c.isArmed()
+	}
+
+	if c.wakeSince.IsZero() {
+		c.wakeSince = now
+	}
+	// Gestures are only reported on the event they are detected in,
+	// so only the wake pattern must be held.
+	if arming.Gesture != "" || now.Sub(c.wakeSince) >= arming.Hold() {
+		c.arm()
+	}
+	return false
+}
+
+// matchWake returns whether the wake gesture or pattern is present in the given hands.
+func (c *Consumer) matchWake(hs map[label]Hand) bool {
+	arming := &c.cfg.Arming
+	if arming.Gesture != "" {
+		if match, ok := compoundGestures[arming.Gesture]; ok {
+			return match(hs)
+		}
+		for _, h := range hs {
+			if matchGesture(h, arming.Gesture) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, h := range hs {
+		if h.Fingers == *arming.Pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Consumer) isArmed() bool {
+	return !c.armedUntil.IsZero()
+}
+
+// expireArming disarms the consumer once the armed window has expired.
+func (c *Consumer) expireArming() {
+	if c.cfg.Arming.Enabled && c.isArmed() && !c.now().Before(c.armedUntil) {
+		c.disarm()
+	}
+}
+
+// bindingsArmed returns whether bindings may be actioned, when arming is disabled or armed.
+func (c *Consumer) bindingsArmed() bool {
+	return !c.cfg.Arming.Enabled || c.isArmed()
+}
+
+// arm enables bindings for the armed timeout, flashing the indicator
+// if the consumer was not already armed.
+func (c *Consumer) arm() {
+	wasArmed := c.isArmed()
+	c.armedUntil = c.now().Add(time.Duration(c.cfg.Arming.ArmedTimeoutMs) * time.Millisecond)
+	if wasArmed {
+		return
+	}
+	c.logger.Info("armed", slog.Time("until", c.armedUntil))
+	c.recordMode()
+	if i := c.cfg.Arming.Indicator; i != nil {
+		c.flash(i.Selector, i.ArmHSBK)
+	}
+}
+
+// disarm stops handling bindings until the wake trigger is detected again.
+func (c *Consumer) disarm() {
+	c.armedUntil = time.Time{}
+	c.logger.Info("disarmed")
+	c.recordMode()
+	if i := c.cfg.Arming.Indicator; i != nil {
+		c.flash(i.Selector, i.DisarmHSBK)
+	}
+}
+
+// rearm extends the armed window after a successful action, if enabled.
+func (c *Consumer) rearm() {
+	if c.cfg.Arming.Enabled && c.cfg.Arming.RearmOnAction && c.isArmed() {
+		c.armedUntil = c.now().Add(time.Duration(c.cfg.Arming.ArmedTimeoutMs) * time.Millisecond)
+	}
+}
+
+// flash briefly pulses the devices matching the selector with the given color.
+func (c *Consumer) flash(selector config.Selector, hsbk *config.HSBK) {
+	if hsbk == nil {
+		return
+	}
+	cond := selectorCondition(selector)
+	if cond == nil {
+		return
+	}
+	serials := targetForCondition(c.ctrl.GetDevices(), cond)
+	if err := sendMultiple(c.ctrl, serials, flashMessage(hsbk)); err != nil {
+		c.logger.Warn("failed to flash indicator", slog.Any("error", err))
+	}
+}
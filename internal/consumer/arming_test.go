@@ -0,0 +1,163 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerArming(t *testing.T) {
+	var (
+		serial0, _     = device.SerialFromHex("d073d5000000")
+		serial1, _     = device.SerialFromHex("d073d5000001")
+		devices        = []device.Device{{Serial: serial0}, {Serial: serial1, Label: "indicator"}}
+		start          = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		wakePattern    = config.FingerPattern{0, 1, 1, 0, 0}
+		holdMs         = 1000
+		armHue, disHue = 120.0, 0.0
+		armHSBK        = &config.HSBK{Hue: &armHue}
+		disarmHSBK     = &config.HSBK{Hue: &disHue}
+		wake           = &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: wakePattern}}}
+		swipe          = &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft}}}
+		wakeSwipe      = &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeUp}}}
+		absent         = &Event{}
+		arming         = config.Arming{
+			Enabled:        true,
+			Pattern:        &wakePattern,
+			HoldMs:         &holdMs,
+			ArmedTimeoutMs: 10000,
+			Indicator: &config.Indicator{
+				Selector:   config.Selector{Type: config.SelectorTypeLabel, Value: "indicator"},
+				ArmHSBK:    armHSBK,
+				DisarmHSBK: disarmHSBK,
+			},
+		}
+	)
+
+	// A step without event checks arming as if no events arrived.
+	type step struct {
+		offset time.Duration
+		event  *Event
+	}
+	testCases := map[string]struct {
+		rearmOnAction bool
+		wakeGesture   config.Gesture
+		presence      config.Presence
+		steps         []step
+		wantMessages  map[device.Serial][]*protocol.Message
+	}{
+		"ignores bindings while disarmed": {
+			steps: []step{{0, swipe}, {time.Second, swipe}},
+		},
+		"wake pattern must be held": {
+			steps: []step{{0, wake}, {500 * time.Millisecond, wake}, {600 * time.Millisecond, swipe}},
+		},
+		"wake gesture arms without being held": {
+			wakeGesture: config.GestureSwipeUp,
+			steps:       []step{{0, wakeSwipe}, {100 * time.Millisecond, swipe}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+		"handles bindings once armed": {
+			steps: []step{{0, wake}, {time.Second, wake}, {2 * time.Second, swipe}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+		"disarms after timeout": {
+			steps: []step{{0, wake}, {time.Second, wake}, {12 * time.Second, swipe}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(armHSBK), flashMessage(disarmHSBK)},
+			},
+		},
+		"does not rearm on action by default": {
+			steps: []step{{0, wake}, {time.Second, wake}, {8 * time.Second, swipe}, {12 * time.Second, swipe}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+				serial1: {flashMessage(armHSBK), flashMessage(disarmHSBK)},
+			},
+		},
+		"disarms after timeout without events": {
+			steps: []step{{0, wake}, {time.Second, wake}, {10 * time.Second, nil}, {11 * time.Second, nil}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(armHSBK), flashMessage(disarmHSBK)},
+			},
+		},
+		"stays armed before timeout without events": {
+			steps: []step{{0, wake}, {time.Second, wake}, {10 * time.Second, nil}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+		"ignores presence while disarmed": {
+			presence: config.PresenceEnter,
+			steps:    []step{{0, swipe}, {time.Second, wake}, {2 * time.Second, wake}, {3 * time.Second, wake}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+		"handles presence once armed": {
+			presence: config.PresenceLeave,
+			steps:    []step{{0, wake}, {time.Second, wake}, {2 * time.Second, absent}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+		"rearms on action": {
+			rearmOnAction: true,
+			steps:         []step{{0, wake}, {time.Second, wake}, {8 * time.Second, swipe}, {12 * time.Second, swipe}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond), setLightPower(true, time.Millisecond)},
+				serial1: {flashMessage(armHSBK)},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{
+				General: config.General{TransitionMs: 1},
+				Arming:  arming,
+				Bindings: []config.Binding{
+					{
+						Gesture:  config.GestureSwipeLeft,
+						Action:   "power_on",
+						Selector: config.Selector{Type: config.SelectorTypeSerial, Serial: serial0},
+					},
+				},
+			}
+			cfg.Arming.RearmOnAction = tc.rearmOnAction
+			if tc.presence != "" {
+				cfg.Bindings = append(cfg.Bindings, config.Binding{
+					Presence: tc.presence,
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeSerial, Serial: serial0},
+				})
+			}
+			if tc.wakeGesture != "" {
+				cfg.Arming.Gesture, cfg.Arming.Pattern = tc.wakeGesture, nil
+			}
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			for _, s := range tc.steps {
+				c.now = func() time.Time { return start.Add(s.offset) }
+				if s.event == nil {
+					c.check()
+					continue
+				}
+				c.HandleEvent(s.event)
+			}
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
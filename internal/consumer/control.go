@@ -0,0 +1,128 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+var (
+	// ErrBindingNotFound is returned when triggering a binding with an unknown name.
+	ErrBindingNotFound = errors.New("binding not found")
+	// ErrNotTriggerable is returned when triggering a binding that needs the
+	// position of a hand, e.g. dial and zone bindings.
+	ErrNotTriggerable = errors.New("binding needs a hand position and cannot be triggered manually")
+	// ErrArmingDisabled is returned when switching mode while arming is disabled.
+	ErrArmingDisabled = errors.New("arming is disabled")
+)
+
+// Mode describes whether the consumer is actioning bindings.
+type Mode string
+
+const (
+	// ModeActive is reported when arming is disabled and bindings are always actioned.
+	ModeActive Mode = "active"
+	// ModeArmed is reported while bindings are actioned within the armed window.
+	ModeArmed Mode = "armed"
+	// ModeDisarmed is reported while waiting for the wake trigger.
+	ModeDisarmed Mode = "disarmed"
+	// ModePaused is reported while bindings are paused.
+	ModePaused Mode = "paused"
+)
+
+// Status is a snapshot of the consumer state.
+type Status struct {
+	LastEvent time.Time `json:"last_event"`
+	Mode      Mode      `json:"mode"`
+}
+
+// Status returns the current state of the consumer.
+func (c *Consumer) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Status{LastEvent: c.lastEvent, Mode: c.mode()}
+}
+
+func (c *Consumer) mode() Mode {
+	switch {
+	case c.paused:
+		return ModePaused
+	case c.cfg.Arming.Enabled:
+		if c.isArmed() && c.now().Before(c.armedUntil) {
+			return ModeArmed
+		}
+		return ModeDisarmed
+	}
+	return ModeActive
+}
+
+// Trigger runs the binding with the given name regardless of gestures,
+// arming and time windows, so that bindings can be tested on demand.
+func (c *Consumer) Trigger(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.namedBindings[name]
+	if !ok {
+		return ErrBindingNotFound
+	}
+	c.logger.Debug("triggered binding", slog.String("binding", name))
+	if _, err := c.send(b, trigger{}); err != nil {
+		if errors.Is(err, errSkipped) {
+			return ErrNotTriggerable
+		}
+		return err
+	}
+	return nil
+}
+
+// SetMode pauses the consumer or resumes it to active, or arms or disarms it
+// as if the wake trigger was detected or the armed window expired.
+func (c *Consumer) SetMode(m Mode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch m {
+	case ModePaused:
+		c.setPaused(true)
+		return nil
+	case ModeActive:
+		c.setPaused(false)
+		return nil
+	case ModeArmed, ModeDisarmed:
+	default:
+		return fmt.Errorf("mode must be one of %s, %s, %s, %s", ModeActive, ModePaused, ModeArmed, ModeDisarmed)
+	}
+
+	if !c.cfg.Arming.Enabled {
+		return ErrArmingDisabled
+	}
+	if m == ModeArmed {
+		c.arm()
+	} else if c.isArmed() {
+		c.disarm()
+	}
+	return nil
+}
+
+// Bindings returns the bindings currently registered.
+func (c *Consumer) Bindings() []config.Binding {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg.Bindings
+}
+
+// SetBindings replaces the registered bindings and the zones their zone
+// selectors resolve against, e.g. when the config is reloaded.
+// The bindings must have been validated against the zones.
+func (c *Consumer) SetBindings(bindings []config.Binding, zones []config.Zone) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg.Bindings = bindings
+	c.cfg.Zones = zones
+	c.initBindings()
+	c.logger.Info("bindings updated", slog.Int("count", len(bindings)))
+}
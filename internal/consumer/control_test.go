@@ -0,0 +1,239 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerTrigger(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		pattern    = config.FingerPattern{0, 1, 1, 0, 0}
+		all        = config.Selector{Type: config.SelectorTypeAll}
+		zones      = []config.Zone{{Name: "all", XMin: 0, XMax: 1, Selector: all}}
+		dial       = &config.Dial{Source: config.DialSourcePinch, Property: config.DialPropertyBrightness}
+	)
+
+	testCases := map[string]struct {
+		bindings []config.Binding
+		name     string
+		wantErr  error
+		wantSent int
+	}{
+		"gesture binding": {
+			bindings: []config.Binding{{Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all}},
+			name:     "off",
+			wantSent: 1,
+		},
+		"pattern binding": {
+			bindings: []config.Binding{{Name: "off", Pattern: &pattern, Action: config.ActionPowerOff, Selector: all}},
+			name:     "off",
+			wantSent: 1,
+		},
+		"presence binding": {
+			bindings: []config.Binding{{Name: "off", Presence: config.PresenceLeave, Action: config.ActionPowerOff, Selector: all}},
+			name:     "off",
+			wantSent: 1,
+		},
+		"ignores time window": {
+			bindings: []config.Binding{{
+				Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all,
+				When: &config.When{Days: []string{"sun"}, From: "00:00", To: "00:01"},
+			}},
+			name:     "off",
+			wantSent: 1,
+		},
+		"zone binding": {
+			bindings: []config.Binding{{Name: "off", Pattern: &pattern, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeZone}}},
+			name:     "off",
+			wantErr:  ErrNotTriggerable,
+		},
+		"dial binding": {
+			bindings: []config.Binding{{Name: "dim", Pattern: &pattern, Action: config.ActionDial, Dial: dial, Selector: all}},
+			name:     "dim",
+			wantErr:  ErrNotTriggerable,
+		},
+		"unknown binding": {
+			bindings: []config.Binding{{Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all}},
+			name:     "on",
+			wantErr:  ErrBindingNotFound,
+		},
+		"unnamed binding": {
+			bindings: []config.Binding{{Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all}},
+			name:     "",
+			wantErr:  ErrBindingNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Zones: zones, Bindings: tc.bindings}
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+			defer c.Close()
+
+			assert.ErrorIs(t, c.Trigger(tc.name), tc.wantErr)
+			assert.Equal(t, tc.wantSent, ctrl.sent())
+		})
+	}
+}
+
+func TestConsumerStatus(t *testing.T) {
+	var (
+		start   = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		pattern = config.FingerPattern{0, 1, 1, 0, 0}
+		wake    = &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: pattern}}}
+	)
+
+	t.Run("active without arming", func(t *testing.T) {
+		c := New(&config.Config{General: config.General{TransitionMs: 1}}, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+		c.now = func() time.Time { return start }
+		defer c.Close()
+
+		assert.Equal(t, Status{Mode: ModeActive}, c.Status())
+		c.HandleEvent(&Event{})
+		assert.Equal(t, Status{LastEvent: start, Mode: ModeActive}, c.Status())
+	})
+
+	t.Run("armed until timeout", func(t *testing.T) {
+		cfg := &config.Config{
+			General: config.General{TransitionMs: 1},
+			Arming:  config.Arming{Enabled: true, Pattern: &pattern, ArmedTimeoutMs: 10000},
+		}
+		now := start
+		c := New(cfg, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+		c.now = func() time.Time { return now }
+		defer c.Close()
+
+		assert.Equal(t, ModeDisarmed, c.Status().Mode)
+		c.HandleEvent(wake)
+		assert.Equal(t, ModeArmed, c.Status().Mode)
+		now = now.Add(10 * time.Second)
+		assert.Equal(t, ModeDisarmed, c.Status().Mode)
+	})
+}
+
+func TestConsumerSetMode(t *testing.T) {
+	pattern := config.FingerPattern{0, 1, 1, 0, 0}
+
+	testCases := map[string]struct {
+		arming       config.Arming
+		modes        []Mode
+		wantErr      string
+		wantMode     Mode
+		wantRecorded []Mode
+	}{
+		"arm": {
+			arming:       config.Arming{Enabled: true, Pattern: &pattern, ArmedTimeoutMs: 10000},
+			modes:        []Mode{ModeArmed},
+			wantMode:     ModeArmed,
+			wantRecorded: []Mode{ModeArmed},
+		},
+		"disarm": {
+			arming:       config.Arming{Enabled: true, Pattern: &pattern, ArmedTimeoutMs: 10000},
+			modes:        []Mode{ModeArmed, ModeDisarmed},
+			wantMode:     ModeDisarmed,
+			wantRecorded: []Mode{ModeArmed, ModeDisarmed},
+		},
+		"pause": {
+			modes:        []Mode{ModePaused},
+			wantMode:     ModePaused,
+			wantRecorded: []Mode{ModePaused},
+		},
+		"resume": {
+			modes:        []Mode{ModePaused, ModeActive},
+			wantMode:     ModeActive,
+			wantRecorded: []Mode{ModePaused, ModeActive},
+		},
+		"resume while armed": {
+			arming:       config.Arming{Enabled: true, Pattern: &pattern, ArmedTimeoutMs: 10000},
+			modes:        []Mode{ModeArmed, ModePaused, ModeActive},
+			wantMode:     ModeArmed,
+			wantRecorded: []Mode{ModeArmed, ModePaused, ModeArmed},
+		},
+		"invalid mode": {
+			arming:   config.Arming{Enabled: true, Pattern: &pattern, ArmedTimeoutMs: 10000},
+			modes:    []Mode{"asleep"},
+			wantErr:  "mode must be one of active, paused, armed, disarmed",
+			wantMode: ModeDisarmed,
+		},
+		"arming disabled": {
+			modes:    []Mode{ModeArmed},
+			wantErr:  ErrArmingDisabled.Error(),
+			wantMode: ModeActive,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Arming: tc.arming}
+			c := New(cfg, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+			defer c.Close()
+			r := &mockRecorder{}
+			c.SetRecorder(r)
+
+			var err error
+			for _, m := range tc.modes {
+				err = c.SetMode(m)
+			}
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantMode, c.Status().Mode)
+
+			var recorded []Mode
+			for _, rec := range r.records {
+				if rec.Type == RecordTypeMode {
+					recorded = append(recorded, rec.Mode)
+				}
+			}
+			assert.Equal(t, tc.wantRecorded, recorded)
+		})
+	}
+}
+
+func TestConsumerSetBindings(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		ctrl       = &mockController{devices: []device.Device{{Serial: serial0}, {Serial: serial1, Label: "Lamp"}}}
+		all        = config.Selector{Type: config.SelectorTypeAll}
+		cfg        = &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Bindings: []config.Binding{{Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all}},
+		}
+		open     = config.FingerPattern{1, 1, 1, 1, 1}
+		bindings = []config.Binding{
+			{Name: "on", Gesture: config.GestureSwipeUp, Action: config.ActionPowerOn, Selector: all},
+			{Pattern: &open, Action: config.ActionPowerOn, Selector: config.Selector{Type: config.SelectorTypeZone}},
+		}
+		// zones are added by the reload along with the zone binding.
+		zones = []config.Zone{{Name: "lamp", XMin: 0, XMax: 0.5, Selector: config.Selector{Type: config.SelectorTypeLabel, Value: "Lamp"}}}
+		x, y  = 0.2, 0.5
+	)
+	c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+	defer c.Close()
+
+	c.SetBindings(bindings, zones)
+	assert.Equal(t, bindings, c.Bindings())
+	assert.ErrorIs(t, c.Trigger("off"), ErrBindingNotFound)
+	assert.NoError(t, c.Trigger("on"))
+	assert.Equal(t, 2, ctrl.sent())
+
+	c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeDown}}})
+	assert.Equal(t, 2, ctrl.sent())
+
+	c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Fingers: open, PalmX: &x, WristY: &y}}})
+	assert.Equal(t, []*protocol.Message{setLightPower(true, time.Millisecond), setLightPower(true, time.Millisecond)}, ctrl.messages[serial1])
+}
@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+// defaultDialRateLimit is the minimum interval between the updates of a dial.
+const defaultDialRateLimit = 100 * time.Millisecond
+
+// errSkipped is returned by a sendFunc that had nothing to send,
+// in which case the binding is not recorded as actioned.
+var errSkipped = errors.New("skipped")
+
+// dialFunc returns a sendFunc setting the dial property of the devices matching
+// the selector from the position of the hand holding the binding pattern.
+// Updates are skipped within the deadband of the last value sent or the rate limit.
+func (c *Consumer) dialFunc(d *config.Dial, selector config.Selector) sendFunc {
+	targets := selectorTargets(selector, c.cfg.Zones)
+	if targets == nil {
+		return nil
+	}
+	rateLimit := cmp.Or(time.Duration(d.RateLimitMs)*time.Millisecond, defaultDialRateLimit)
+
+	var (
+		last     float64
+		lastSent time.Time
+	)
+	return func(ctrl lanController, t trigger) error {
+		if t.source == nil {
+			return fmt.Errorf("%w: no hand position", errSkipped)
+		}
+		pos, ok := t.source.Position(d.Source)
+		if !ok {
+			return fmt.Errorf("%w: no %s reported", errSkipped, d.Source)
+		}
+
+		v := dialValue(d, pos)
+		now := c.now()
+		if !lastSent.IsZero() && (math.Abs(v-last) <= d.Deadband || now.Sub(lastSent) < rateLimit) {
+			return errSkipped
+		}
+		serials, err := targets(ctrl, t)
+		if err != nil {
+			return err
+		}
+		last, lastSent = v, now
+		return sendMultiple(ctrl, serials, setColor(dialHSBK(d.Property, v), rateLimit))
+	}
+}
+
+// dialValue maps the given position to the output range of the dial.
+func dialValue(d *config.Dial, pos float64) float64 {
+	inMin, inMax := d.InputRange()
+	outMin, outMax := d.OutputRange()
+
+	x := min(max((pos-inMin)/(inMax-inMin), 0), 1)
+	switch d.Curve {
+	case config.DialCurveEaseIn:
+		x = x * x
+	case config.DialCurveEaseOut:
+		x = 1 - (1-x)*(1-x)
+	}
+	return outMin + x*(outMax-outMin)
+}
+
+// dialHSBK returns the HSBK setting only the given property to v.
+func dialHSBK(p config.DialProperty, v float64) *config.HSBK {
+	switch p {
+	case config.DialPropertyHue:
+		return &config.HSBK{Hue: &v}
+	case config.DialPropertyKelvin:
+		k := uint16(math.Round(v))
+		return &config.HSBK{Kelvin: &k}
+	}
+	return &config.HSBK{Brightness: &v}
+}
@@ -0,0 +1,177 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialValue(t *testing.T) {
+	testCases := map[string]struct {
+		dial config.Dial
+		pos  float64
+		want float64
+	}{
+		"default ranges": {
+			dial: config.Dial{Property: config.DialPropertyBrightness},
+			pos:  0.25,
+			want: 25,
+		},
+		"clamps below input range": {
+			dial: config.Dial{Property: config.DialPropertyBrightness, InputMin: 0.2, InputMax: 0.6},
+			pos:  0.1,
+			want: 0,
+		},
+		"clamps above input range": {
+			dial: config.Dial{Property: config.DialPropertyBrightness, InputMin: 0.2, InputMax: 0.6},
+			pos:  0.9,
+			want: 100,
+		},
+		"inverted input range": {
+			dial: config.Dial{Property: config.DialPropertyBrightness, InputMin: 1, InputMax: 0},
+			pos:  0.2,
+			want: 80,
+		},
+		"output range": {
+			dial: config.Dial{Property: config.DialPropertyKelvin, OutputMin: 2500, OutputMax: 6500},
+			pos:  0.5,
+			want: 4500,
+		},
+		"property range": {
+			dial: config.Dial{Property: config.DialPropertyHue},
+			pos:  0.5,
+			want: 180,
+		},
+		"ease in": {
+			dial: config.Dial{Property: config.DialPropertyBrightness, Curve: config.DialCurveEaseIn},
+			pos:  0.5,
+			want: 25,
+		},
+		"ease out": {
+			dial: config.Dial{Property: config.DialPropertyBrightness, Curve: config.DialCurveEaseOut},
+			pos:  0.5,
+			want: 75,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, tc.want, dialValue(&tc.dial, tc.pos), 1e-9)
+		})
+	}
+}
+
+func TestConsumerDial(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		start      = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		pinch      = config.FingerPattern{1, 1, 0, 0, 0}
+		open       = config.FingerPattern{1, 1, 1, 1, 1}
+		hand       = func(fingers config.FingerPattern, pos float64) *Event {
+			return &Event{Hands: []Hand{{Label: RightHandLabel, Fingers: fingers, Pinch: &pos, PalmX: &pos, WristY: &pos}}}
+		}
+		brightness = func(v float64) *protocol.Message {
+			return setColor(&config.HSBK{Brightness: &v}, 200*time.Millisecond)
+		}
+	)
+
+	type step struct {
+		offset time.Duration
+		event  *Event
+	}
+	testCases := map[string]struct {
+		source       config.DialSource
+		tracking     config.Tracking
+		steps        []step
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"sets property while pattern is held": {
+			steps: []step{{0, hand(pinch, 0.5)}, {time.Second, hand(pinch, 0.8)}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(50), brightness(80)},
+			},
+		},
+		"ignores other patterns": {
+			steps: []step{{0, hand(open, 0.5)}, {time.Second, hand(open, 0.8)}},
+		},
+		"ignores changes within deadband": {
+			steps: []step{{0, hand(pinch, 0.5)}, {time.Second, hand(pinch, 0.51)}, {2 * time.Second, hand(pinch, 0.52)}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(50)},
+			},
+		},
+		"rate limits updates": {
+			steps: []step{
+				{0, hand(pinch, 0.5)},
+				{100 * time.Millisecond, hand(pinch, 0.6)},
+				{200 * time.Millisecond, hand(pinch, 0.7)},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(50), brightness(70)},
+			},
+		},
+		"ignores hands without position": {
+			steps: []step{{0, &Event{Hands: []Hand{{Label: RightHandLabel, Fingers: pinch}}}}},
+		},
+		"mirrors palm_x": {
+			source:   config.DialSourcePalmX,
+			tracking: config.Tracking{MirrorHorizontal: true},
+			steps:    []step{{0, hand(pinch, 0.2)}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(80)},
+			},
+		},
+		"flips wrist_y": {
+			source:   config.DialSourceWristY,
+			tracking: config.Tracking{FlipVertical: true},
+			steps:    []step{{0, hand(pinch, 0.3)}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(70)},
+			},
+		},
+		"does not mirror pinch": {
+			tracking: config.Tracking{MirrorHorizontal: true, FlipVertical: true},
+			steps:    []step{{0, hand(pinch, 0.2)}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {brightness(20)},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			source := tc.source
+			if source == "" {
+				source = config.DialSourcePinch
+			}
+			dial := &config.Dial{Source: source, Property: config.DialPropertyBrightness, Deadband: 2, RateLimitMs: 200}
+			bindings := []config.Binding{{Pattern: &pinch, Action: config.ActionDial, Dial: dial, Selector: config.Selector{Type: config.SelectorTypeAll}}}
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Tracking: tc.tracking, Bindings: bindings}
+			ctrl := &mockController{devices: []device.Device{{Serial: serial0}}}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			defer c.Close()
+			r := &mockRecorder{}
+			c.SetRecorder(r)
+
+			for _, s := range tc.steps {
+				c.now = func() time.Time { return start.Add(s.offset) }
+				c.HandleEvent(s.event)
+			}
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+
+			var actions int
+			for _, rec := range r.records {
+				if rec.Type == RecordTypeAction {
+					actions++
+				}
+			}
+			assert.Equal(t, len(tc.wantMessages[serial0]), actions)
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package consumer
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"math"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/alessio-palumbo/lifx-force/internal/config"
@@ -9,6 +14,15 @@ import (
 	"github.com/alessio-palumbo/lifxlan-go/pkg/messages"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
 	"github.com/alessio-palumbo/lifxprotocol-go/gen/protocol/enums"
+	"github.com/alessio-palumbo/lifxprotocol-go/gen/protocol/packets"
+)
+
+const (
+	// presenceCheckPeriod is how often presence is checked while no events arrive.
+	presenceCheckPeriod = time.Second
+	// presenceTimeout is how long without events before hands are considered gone,
+	// as fingertrack may stop reporting events when no hands are detected.
+	presenceTimeout = 2 * time.Second
 )
 
 var compoundGestures = map[config.Gesture]func(map[label]Hand) bool{
@@ -30,6 +44,21 @@ var compoundGestures = map[config.Gesture]func(map[label]Hand) bool{
 	},
 }
 
+var (
+	mirroredLabels = map[label]label{
+		LeftHandLabel:  RightHandLabel,
+		RightHandLabel: LeftHandLabel,
+	}
+	mirroredGestures = map[config.Gesture]config.Gesture{
+		config.GestureSwipeLeft:  config.GestureSwipeRight,
+		config.GestureSwipeRight: config.GestureSwipeLeft,
+	}
+	flippedGestures = map[config.Gesture]config.Gesture{
+		config.GestureSwipeUp:   config.GestureSwipeDown,
+		config.GestureSwipeDown: config.GestureSwipeUp,
+	}
+)
+
 type label string
 
 const (
@@ -41,6 +70,28 @@ type Hand struct {
 	Label   label                `json:"label"`
 	Fingers config.FingerPattern `json:"fingers"`
 	Gesture config.Gesture       `json:"gesture,omitempty"`
+	// WristY, PalmX and Pinch are the optional positions of the hand,
+	// normalised to 0-1, which drive dial bindings.
+	WristY *float64 `json:"wrist_y,omitempty"`
+	PalmX  *float64 `json:"palm_x,omitempty"`
+	Pinch  *float64 `json:"pinch,omitempty"`
+}
+
+// Position returns the given position of the hand, if reported.
+func (h Hand) Position(s config.DialSource) (float64, bool) {
+	var p *float64
+	switch s {
+	case config.DialSourceWristY:
+		p = h.WristY
+	case config.DialSourcePalmX:
+		p = h.PalmX
+	case config.DialSourcePinch:
+		p = h.Pinch
+	}
+	if p == nil {
+		return 0, false
+	}
+	return *p, true
 }
 
 type Event struct {
@@ -52,41 +103,159 @@ type lanController interface {
 	GetDevices() []device.Device
 }
 
-type sendFunc func(ctrl lanController) error
+// trigger describes what caused a binding to be actioned.
+type trigger struct {
+	Binding string
+	Gesture config.Gesture
+	Hand    label
+	Fingers config.FingerPattern
+	// Pattern is the finger pattern of the actioned binding, which may
+	// differ from the observed fingers within its tolerance.
+	Pattern *config.FingerPattern
+	// TriggerID is the id of the device trigger of the actioned binding,
+	// empty for bindings without one, see config.Binding.TriggerID.
+	TriggerID string
+	Presence  config.Presence
+	Time      time.Time
+	// source is the hand that triggered a single hand binding, with its positions.
+	source *Hand
+}
+
+type sendFunc func(ctrl lanController, t trigger) error
 
 type Consumer struct {
-	ctrl            lanController
-	cfg             *config.Config
-	logger          *slog.Logger
-	fingerBindings  map[config.FingerPattern]sendFunc
-	gestureBindings map[config.Gesture]sendFunc
+	// mu serialises events with the bindings triggered by the control API.
+	mu               sync.Mutex
+	ctrl             lanController
+	cfg              *config.Config
+	logger           *slog.Logger
+	now              func() time.Time
+	fingerBindings   map[config.FingerPattern][]*binding
+	countBindings    map[countKey][]*binding
+	fallbackBindings []*binding
+	gestureBindings  map[config.Gesture][]*binding
+	presenceBindings []*presenceBinding
+	namedBindings    map[string]*binding
+	timers           *timers
+	executor         *executor
+	webhooks         *executor
+	// history keeps the latest finger patterns reported for each hand.
+	history map[label]*handHistory
+	// present reports whether hands were detected in the latest event
+	// and presentSince when that last changed.
+	present      bool
+	presentSince time.Time
+	// armedUntil is when the armed window expires, zero when disarmed,
+	// and wakeSince when the wake trigger was first detected.
+	armedUntil time.Time
+	wakeSince  time.Time
+	// lastEvent is when the latest event was received.
+	lastEvent time.Time
+	recorder  Recorder
+	// paused stops bindings from being actioned while events are still handled,
+	// onPause is notified when it changes.
+	paused  bool
+	onPause func(paused bool)
+	// held keeps the finger binding actioned by each hand until its pattern is released.
+	held map[label]*binding
+	// unhandled rate limits the warnings of events matching no binding.
+	unhandled *unhandledLog
+}
+
+// countKey identifies the count bindings for a number of fingers,
+// on the given hand or on any hand when empty.
+type countKey struct {
+	count int
+	hand  label
+}
+
+// binding is a registered action, only handled while its time window is active.
+type binding struct {
+	name string
+	when *config.When
+	send sendFunc
+	// feedback is pulsed when the binding is actioned, nil when disabled.
+	feedback *config.Feedback
+	// togglesPause is set for bindings toggling pause, which are handled while paused.
+	togglesPause bool
+	// repeats is set for finger bindings actioned on every event while their
+	// pattern is held, rather than once per hold.
+	repeats bool
+	// pattern is the finger pattern of the binding, nil for other bindings.
+	pattern *config.FingerPattern
+	// triggerID is the id of the device trigger of the binding.
+	triggerID string
+	// tolerance is the number of fingers a pattern binding may differ by.
+	tolerance int
+}
+
+// presenceBinding is a binding triggered when hands appear or are gone
+// for at least the given duration. It fires once per presence change.
+type presenceBinding struct {
+	binding
+	presence config.Presence
+	after    time.Duration
+	fired    bool
 }
 
 func New(cfg *config.Config, ctrl lanController, logger *slog.Logger) *Consumer {
-	gb, fb := initBindings(cfg, logger, ctrl.GetDevices())
-	return &Consumer{
-		cfg:             cfg,
-		ctrl:            ctrl,
-		logger:          logger,
-		gestureBindings: gb,
-		fingerBindings:  fb,
+	c := &Consumer{
+		cfg:       cfg,
+		ctrl:      ctrl,
+		logger:    logger,
+		now:       time.Now,
+		history:   make(map[label]*handHistory),
+		held:      make(map[label]*binding),
+		unhandled: newUnhandledLog(logger),
+		timers:    newTimers(cfg, ctrl, logger),
+		executor:  newExecutor(max(cfg.Exec.MaxConcurrent, 1), logger),
+		webhooks:  newExecutor(maxConcurrentWebhooks, logger),
 	}
+	c.initBindings()
+	c.presentSince = c.now()
+	c.timers.resume()
+	return c
+}
+
+// Close stops the pending timers, running commands and webhooks.
+func (c *Consumer) Close() {
+	c.timers.close()
+	c.executor.close()
+	c.webhooks.close()
 }
 
 func (c *Consumer) HandleEvent(event *Event) {
 	c.logger.Debug("processing event", slog.Any("event", event))
 
-	hs := make(map[label]Hand, len(event.Hands))
-	for _, h := range event.Hands {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastEvent = c.now()
+	c.unhandled.flush(c.lastEvent)
+
+	hands := c.orient(event.Hands)
+	c.recordEvent(hands)
+	hs := make(map[label]Hand, len(hands))
+	for _, h := range hands {
 		hs[h.Label] = h
 	}
+	c.updateHistory(hs)
+	c.releaseHeld(hs)
+	if c.paused {
+		c.handlePaused(hs, hands)
+		return
+	}
+	c.expireArming()
+	c.handlePresence(len(hs) > 0, c.lastEvent)
+	if !c.handleArming(hs) {
+		return
+	}
 
 	// Try compound gestures
 	for g, match := range compoundGestures {
 		if match(hs) {
-			if f, ok := c.gestureBindings[g]; ok {
+			if b, ok := c.activeBinding(c.gestureBindings[g]); ok {
 				c.logger.Debug("actioned compound gesture", slog.Any("gesture", g))
-				f(c.ctrl)
+				c.action(b, trigger{Gesture: g})
 				return
 			}
 			c.logger.Debug("unhandled compound gesture", slog.Any("gesture", g))
@@ -94,80 +263,509 @@ func (c *Consumer) HandleEvent(event *Event) {
 		}
 	}
 
-	// Fallback: single-hand gestures
-	for _, h := range event.Hands {
-		if h.Gesture != "" && c.gestureBindings != nil {
-			if f, ok := c.gestureBindings[h.Gesture]; ok {
+	// Fallback: single-hand gestures, then finger patterns
+	var unmatched *Hand
+	for _, h := range hands {
+		if h.Gesture != "" {
+			if b, ok := c.activeBinding(c.gestureBindings[h.Gesture]); ok {
 				c.logger.Debug("actioned gesture", slog.Any("gesture", h.Gesture))
-				f(c.ctrl)
+				c.action(b, trigger{Gesture: h.Gesture, Hand: h.Label, Fingers: h.Fingers, source: &h})
 				// Skip finger binding when gesture is available.
 				continue
 			}
-			c.logger.Warn("unhandled gesture", slog.Any("hand", h.Label), slog.Any("gesture", h.Gesture))
+			c.unhandled.gesture(c.now(), h)
+		}
+		if c.handleFingers(h) {
+			continue
+		}
+		if h.Gesture != "" && unmatched == nil {
+			unmatched = &h
 		}
+	}
 
-		if c.fingerBindings != nil {
-			if f, ok := c.fingerBindings[h.Fingers]; ok {
-				f(c.ctrl)
-				continue
-			}
-			c.logger.Warn("unhandled finger binding", slog.Any("hand", h.Label), slog.Any("fingers", h.Fingers))
+	// The fallback runs once per event, when a gesture matched neither
+	// a gesture nor a finger binding.
+	if unmatched != nil {
+		if b, ok := c.activeBinding(c.fallbackBindings); ok {
+			c.logger.Debug("actioned fallback", slog.Any("gesture", unmatched.Gesture))
+			c.action(b, trigger{Gesture: unmatched.Gesture, Hand: unmatched.Label, Fingers: unmatched.Fingers, source: unmatched})
+		}
+	}
+}
+
+// handleFingers runs the finger binding of the given hand once its pattern
+// is stable, returning whether one matched.
+func (c *Consumer) handleFingers(h Hand) bool {
+	if !c.isStable(h.Label) {
+		c.logger.Debug("unstable finger pattern", slog.Any("hand", h.Label), slog.Any("fingers", h.Fingers))
+		return false
+	}
+	if b, ok := c.fingerBinding(h); ok {
+		c.actionFingers(b, h)
+		return true
+	}
+	delete(c.held, h.Label)
+	c.unhandled.pattern(c.now(), h)
+	return false
+}
+
+// actionFingers runs a finger binding for the given hand. As patterns are
+// reported on every event, a binding only runs again once its pattern has
+// been released, unless it repeats while held.
+func (c *Consumer) actionFingers(b *binding, h Hand) {
+	if c.held[h.Label] == b && !b.repeats {
+		return
+	}
+	c.held[h.Label] = b
+	c.action(b, trigger{Hand: h.Label, Fingers: h.Fingers, source: &h})
+}
+
+// releaseHeld forgets the finger bindings of the hands no longer reported.
+func (c *Consumer) releaseHeld(hs map[label]Hand) {
+	for l := range c.held {
+		if _, ok := hs[l]; !ok {
+			delete(c.held, l)
 		}
 	}
 }
 
-func initBindings(cfg *config.Config, logger *slog.Logger, devices []device.Device) (map[config.Gesture]sendFunc, map[config.FingerPattern]sendFunc) {
-	gb := make(map[config.Gesture]sendFunc)
-	fb := make(map[config.FingerPattern]sendFunc)
-	for _, b := range cfg.Bindings {
-		if b.Gesture != "" {
-			if f := bindingSendFunc(cfg, devices, b.Action, b.HSBK, b.Selector); f != nil {
-				gb[b.Gesture] = f
-				logger.Debug("registered gesture binding", slog.Any("gesture", b.Gesture))
+// fingerBinding returns the active binding for the fingers of the given hand,
+// patterns taking precedence over counts.
+func (c *Consumer) fingerBinding(h Hand) (*binding, bool) {
+	if b, ambiguous := c.patternBinding(h.Fingers); b != nil || ambiguous {
+		return b, b != nil
+	}
+	return c.activeBinding(c.handCountBindings(h))
+}
+
+// patternBinding returns the active binding with the closest pattern within
+// its tolerance of the given fingers. It returns no binding and ambiguous
+// when several bindings are equally close.
+func (c *Consumer) patternBinding(fingers config.FingerPattern) (b *binding, ambiguous bool) {
+	if b, ok := c.activeBinding(c.fingerBindings[fingers]); ok {
+		return b, false
+	}
+	var (
+		now      = c.now()
+		distance int
+	)
+	for p, bs := range c.fingerBindings {
+		d := p.Distance(fingers)
+		if d == 0 || b != nil && d > distance {
+			continue
+		}
+		i := slices.IndexFunc(bs, func(b *binding) bool { return b.tolerance >= d && b.when.Active(now) })
+		switch {
+		case i < 0:
+		case b == nil || d < distance:
+			b, distance, ambiguous = bs[i], d, false
+		default:
+			ambiguous = true
+		}
+	}
+	if ambiguous {
+		c.logger.Debug("ambiguous finger pattern, several bindings are equally close",
+			slog.Any("fingers", fingers), slog.Int("distance", distance))
+		return nil, true
+	}
+	return b, false
+}
+
+// handCountBindings returns the count bindings matching the fingers up
+// of the given hand, those specific to the hand first.
+func (c *Consumer) handCountBindings(h Hand) []*binding {
+	n := h.Fingers.Count()
+	bs := slices.Clone(c.countBindings[countKey{count: n, hand: h.Label}])
+	return append(bs, c.countBindings[countKey{count: n}]...)
+}
+
+// activeBinding returns the first binding in the list whose time window
+// is active according to the consumer clock.
+func (c *Consumer) activeBinding(bs []*binding) (*binding, bool) {
+	now := c.now()
+	for _, b := range bs {
+		if b.when.Active(now) {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// action runs the given binding, re-arming the consumer on success.
+func (c *Consumer) action(b *binding, t trigger) {
+	serials, err := c.send(b, t)
+	if errors.Is(err, errSkipped) {
+		c.logger.Debug("skipped binding", slog.String("binding", b.name), slog.Any("reason", err))
+		return
+	}
+	c.feedback(b.feedback, serials, err)
+	if err != nil {
+		c.logger.Warn("failed to action binding", slog.String("binding", b.name), slog.Any("error", err))
+		return
+	}
+	c.rearm()
+}
+
+// send runs the given binding with the trigger that caused it,
+// returning the devices messages were sent to.
+func (c *Consumer) send(b *binding, t trigger) ([]device.Serial, error) {
+	t.Binding = b.name
+	t.Pattern = b.pattern
+	t.TriggerID = b.triggerID
+	t.Time = c.now()
+	ctrl := &recordingController{lanController: c.ctrl}
+	err := b.send(ctrl, t)
+	if errors.Is(err, errSkipped) {
+		return nil, err
+	}
+	c.recordAction(t, ctrl.serials, err)
+	return ctrl.serials, err
+}
+
+// orient returns a copy of the given hands with labels, gesture directions
+// and positions remapped according to the camera orientation settings.
+func (c *Consumer) orient(hands []Hand) []Hand {
+	mirror, flip := c.cfg.Tracking.MirrorHorizontal, c.cfg.Tracking.FlipVertical
+	if !mirror && !flip {
+		return hands
+	}
+
+	oriented := make([]Hand, len(hands))
+	for i, h := range hands {
+		if mirror {
+			if l, ok := mirroredLabels[h.Label]; ok {
+				h.Label = l
+			}
+			if g, ok := mirroredGestures[h.Gesture]; ok {
+				h.Gesture = g
+			}
+			if h.PalmX != nil {
+				x := 1 - *h.PalmX
+				h.PalmX = &x
+			}
+		}
+		if flip {
+			if g, ok := flippedGestures[h.Gesture]; ok {
+				h.Gesture = g
 			}
+			if h.WristY != nil {
+				y := 1 - *h.WristY
+				h.WristY = &y
+			}
+		}
+		oriented[i] = h
+	}
+	return oriented
+}
+
+// updateHistory records the finger patterns of the given hands and
+// drops the history of hands that are no longer reported.
+func (c *Consumer) updateHistory(hs map[label]Hand) {
+	size := max(c.cfg.Tracking.StableFrames, 1)
+	for l := range c.history {
+		if _, ok := hs[l]; !ok {
+			delete(c.history, l)
+		}
+	}
+	for l, h := range hs {
+		hh, ok := c.history[l]
+		if !ok {
+			hh = &handHistory{}
+			c.history[l] = hh
+		}
+		hh.push(h.Fingers, size)
+	}
+}
+
+// isStable returns whether the latest pattern of the given hand has been
+// reported for the number of consecutive events required by the config.
+func (c *Consumer) isStable(l label) bool {
+	hh, ok := c.history[l]
+	return ok && hh.stable(max(c.cfg.Tracking.StableFrames, 1))
+}
+
+// Run periodically checks arming and presence until the context is done,
+// so that the armed window expires and presence bindings fire even when no events arrive.
+func (c *Consumer) Run(ctx context.Context) {
+	ticker := time.NewTicker(presenceCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.check()
+		}
+	}
+}
+
+// check expires the armed window and fires the presence bindings whose dwell time
+// has elapsed, considering hands gone since the latest event once no events arrive
+// for the presence timeout.
+func (c *Consumer) check() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireArming()
+	if c.paused {
+		return
+	}
+
+	present := c.present && c.now().Sub(c.lastEvent) < presenceTimeout
+	// Hands are gone since the latest event, or since resumed
+	// as no events are handled while paused.
+	since := c.lastEvent
+	if c.presentSince.After(since) {
+		since = c.presentSince
+	}
+	c.handlePresence(present, since)
+}
+
+// handlePresence tracks whether hands are detected and fires the presence
+// bindings whose dwell time has elapsed since the last change, at the given time.
+func (c *Consumer) handlePresence(present bool, at time.Time) {
+	now := c.now()
+	if present != c.present {
+		c.present = present
+		c.presentSince = at
+		for _, pb := range c.presenceBindings {
+			pb.fired = false
+		}
+	}
+
+	want := config.PresenceLeave
+	if present {
+		want = config.PresenceEnter
+	}
+	for _, pb := range c.presenceBindings {
+		if pb.presence != want || pb.fired || now.Sub(c.presentSince) < pb.after || !pb.when.Active(now) {
 			continue
 		}
-		if f := bindingSendFunc(cfg, devices, b.Action, b.HSBK, b.Selector); f != nil {
-			fb[*b.Pattern] = f
-			logger.Debug("registered finger binding", slog.Any("fingers", b.Pattern))
+		pb.fired = true
+		// Like other bindings, presence is ignored rather than deferred while disarmed.
+		if !c.bindingsArmed() {
+			c.logger.Debug("disarmed, ignoring presence", slog.Any("presence", pb.presence))
+			continue
 		}
+		c.logger.Debug("actioned presence", slog.Any("presence", pb.presence))
+		c.action(&pb.binding, trigger{Presence: pb.presence})
 	}
-	return gb, fb
 }
 
-func bindingSendFunc(cfg *config.Config, devices []device.Device, action config.Action, hsbk *config.HSBK, selector config.Selector) sendFunc {
-	var msg *protocol.Message
+// handHistory is a buffer of the latest finger patterns reported for a hand.
+type handHistory []config.FingerPattern
+
+// push appends a pattern to the history, keeping at most size entries.
+func (h *handHistory) push(p config.FingerPattern, size int) {
+	*h = append(*h, p)
+	if len(*h) > size {
+		*h = (*h)[len(*h)-size:]
+	}
+}
+
+// stable returns whether the history is full and all its patterns match.
+func (h handHistory) stable(size int) bool {
+	if len(h) < size {
+		return false
+	}
+	for _, p := range h {
+		if p != h[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Consumer) initBindings() {
+	c.gestureBindings = make(map[config.Gesture][]*binding)
+	c.fingerBindings = make(map[config.FingerPattern][]*binding)
+	c.countBindings = make(map[countKey][]*binding)
+	c.fallbackBindings = nil
+	c.namedBindings = make(map[string]*binding)
+	c.presenceBindings = nil
+	for i, b := range c.cfg.Bindings {
+		f := c.bindingSendFunc(b)
+		if f == nil {
+			c.logger.Warn("ignored binding without targets", slog.Int("index", i), slog.String("binding", b.Name))
+			continue
+		}
+		bb := &binding{
+			name:         b.Name,
+			when:         b.When,
+			send:         f,
+			feedback:     c.bindingFeedback(b),
+			togglesPause: b.Action == config.ActionTogglePause,
+			repeats:      b.Action == config.ActionDial,
+			pattern:      b.Pattern,
+			triggerID:    b.TriggerID(),
+			tolerance:    b.Tolerance,
+		}
+		switch {
+		case b.Gesture != "":
+			c.gestureBindings[b.Gesture] = append(c.gestureBindings[b.Gesture], bb)
+			c.logger.Debug("registered gesture binding", slog.Any("gesture", b.Gesture))
+		case b.Pattern != nil:
+			c.fingerBindings[*b.Pattern] = append(c.fingerBindings[*b.Pattern], bb)
+			c.logger.Debug("registered finger binding", slog.Any("fingers", b.Pattern), slog.Int("tolerance", b.Tolerance))
+		case b.Count != nil:
+			k := countKey{count: *b.Count, hand: label(b.Hand)}
+			c.countBindings[k] = append(c.countBindings[k], bb)
+			c.logger.Debug("registered count binding", slog.Int("count", *b.Count), slog.String("hand", b.Hand))
+		case b.Fallback:
+			c.fallbackBindings = append(c.fallbackBindings, bb)
+			c.logger.Debug("registered fallback binding")
+		case b.Presence != "":
+			pb := &presenceBinding{
+				binding:  *bb,
+				presence: b.Presence,
+				after:    time.Duration(b.AfterMs) * time.Millisecond,
+				// Hands are assumed absent on start, so leave bindings
+				// only fire after hands have been seen.
+				fired: b.Presence == config.PresenceLeave,
+			}
+			c.presenceBindings = append(c.presenceBindings, pb)
+			bb = &pb.binding
+			c.logger.Debug("registered presence binding", slog.Any("presence", b.Presence), slog.Int("after_ms", b.AfterMs))
+		}
+		if b.Name != "" {
+			c.namedBindings[b.Name] = bb
+		}
+	}
+}
+
+// bindingSendFunc returns the sendFunc for the given binding, which runs
+// its action, or else action, according to the condition of the binding.
+func (c *Consumer) bindingSendFunc(b config.Binding) sendFunc {
+	var send sendFunc
+	switch b.Action {
+	case config.ActionScheduleAction:
+		send = c.timers.scheduleFunc(b.Schedule, b.Selector, b.TransitionMs)
+	case config.ActionCancelTimers:
+		send = func(lanController, trigger) error {
+			c.timers.cancelAll()
+			return nil
+		}
+	case config.ActionExec:
+		send = c.executor.execFunc(b.Exec)
+	case config.ActionWebhook:
+		send = c.webhooks.webhookFunc(b.Webhook)
+	case config.ActionTogglePause:
+		send = c.togglePauseFunc()
+	case config.ActionDial:
+		send = c.dialFunc(b.Dial, b.Selector)
+	default:
+		send = actionSendFunc(b.Action, b.HSBK, selectorTargets(b.Selector, c.cfg.Zones), transition(c.cfg, b.TransitionMs))
+	}
+	if send == nil || b.If == nil {
+		return send
+	}
+
+	var otherwise sendFunc
+	if b.Else != nil {
+		otherwise = actionSendFunc(b.Else.Action, b.Else.HSBK, selectorTargets(b.Selector, nil), transition(c.cfg, b.TransitionMs))
+	}
+	return func(ctrl lanController, t trigger) error {
+		if matchCondition(ctrl.GetDevices(), b.Selector, b.If) {
+			return send(ctrl, t)
+		}
+		if otherwise != nil {
+			return otherwise(ctrl, t)
+		}
+		return nil
+	}
+}
+
+// actionSendFunc returns a sendFunc sending the messages for the given action
+// to the devices returned by targets at the time it is called.
+// The messages transition over the given duration.
+func actionSendFunc(action config.Action, hsbk *config.HSBK, targets targetFunc, d time.Duration) sendFunc {
+	var msgs []*protocol.Message
 	switch action {
 	case config.ActionPowerOn:
-		msg = messages.SetPowerOn()
+		// Set the color first, if given, so that the device turns on with it.
+		if hsbk != nil && !hsbk.IsEmpty() {
+			msgs = append(msgs, setColor(hsbk, d))
+		}
+		msgs = append(msgs, setLightPower(true, d))
 	case config.ActionPowerOff:
-		msg = messages.SetPowerOff()
+		msgs = append(msgs, setLightPower(false, d))
 	case config.ActionPowerSetColor:
-		msg = messages.SetColor(
-			hsbk.Hue, hsbk.Saturation, hsbk.Brightness, hsbk.Kelvin,
-			time.Duration(cfg.General.TransitionMs)*time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW,
-		)
+		msgs = append(msgs, setColor(hsbk, d))
+	case config.ActionFlash:
+		msgs = append(msgs, flashMessage(hsbk))
 	default:
 		return nil
 	}
 
-	var serials []device.Serial
+	if targets == nil {
+		return nil
+	}
+	return func(ctrl lanController, t trigger) error {
+		serials, err := targets(ctrl, t)
+		if err != nil {
+			return err
+		}
+		return sendMultiple(ctrl, serials, msgs...)
+	}
+}
+
+// transition returns the binding transition, defaulting to the global one when not set.
+func transition(cfg *config.Config, ms int) time.Duration {
+	if ms <= 0 {
+		ms = cfg.General.TransitionMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func setColor(hsbk *config.HSBK, d time.Duration) *protocol.Message {
+	return messages.SetColor(hsbk.Hue, hsbk.Saturation, hsbk.Brightness, hsbk.Kelvin, d, enums.LightWaveformLIGHTWAVEFORMSAW)
+}
+
+// setLightPower returns a message turning a light on or off over the given duration.
+func setLightPower(on bool, d time.Duration) *protocol.Message {
+	var level uint16
+	if on {
+		level = math.MaxUint16
+	}
+	return protocol.NewMessage(&packets.LightSetPower{Level: level, Duration: uint32(d.Milliseconds())})
+}
+
+// matchCondition returns whether all the devices matching the selector
+// satisfy the condition. It is false when no device matches the selector.
+func matchCondition(devices []device.Device, selector config.Selector, cond *config.Condition) bool {
+	match := selectorCondition(selector)
+	if match == nil {
+		return false
+	}
+	var found bool
+	for _, d := range devices {
+		if !match(&d) {
+			continue
+		}
+		if !cond.Match(&d) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// selectorCondition returns the condition a device must satisfy to be
+// targeted by the given selector, or nil if the selector is unknown.
+func selectorCondition(selector config.Selector) func(d *device.Device) bool {
 	switch selector.Type {
 	case config.SelectorTypeAll:
-		serials = targetForCondition(devices, func(d *device.Device) bool { return true })
+		return func(d *device.Device) bool { return true }
 	case config.SelectorTypeLabel:
-		serials = targetForCondition(devices, func(d *device.Device) bool { return d.Label == selector.Value })
+		return func(d *device.Device) bool { return d.Label == selector.Value }
 	case config.SelectorTypeGroup:
-		serials = targetForCondition(devices, func(d *device.Device) bool { return d.Group == selector.Value })
+		return func(d *device.Device) bool { return d.Group == selector.Value }
 	case config.SelectorTypeLocation:
-		serials = targetForCondition(devices, func(d *device.Device) bool { return d.Location == selector.Value })
+		return func(d *device.Device) bool { return d.Location == selector.Value }
 	case config.SelectorTypeSerial:
-		serials = []device.Serial{selector.Serial}
-	default:
-		return nil
+		return func(d *device.Device) bool { return d.Serial == selector.Serial }
 	}
-
-	return func(ctrl lanController) error { return sendMultiple(ctrl, serials, msg) }
+	return nil
 }
 
 func targetForCondition(devices []device.Device, cond func(d *device.Device) bool) []device.Serial {
@@ -180,15 +778,27 @@ func targetForCondition(devices []device.Device, cond func(d *device.Device) boo
 	return targets
 }
 
-func sendMultiple(ctrl lanController, serials []device.Serial, msg *protocol.Message) error {
+func sendMultiple(ctrl lanController, serials []device.Serial, msgs ...*protocol.Message) error {
 	for _, s := range serials {
-		if err := ctrl.Send(s, msg); err != nil {
-			return err
+		for _, msg := range msgs {
+			if err := ctrl.Send(s, msg); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// flashMessage returns a transient pulse of the given color, after which
+// a device returns to its original color.
+func flashMessage(hsbk *config.HSBK) *protocol.Message {
+	msg := messages.SetColor(hsbk.Hue, hsbk.Saturation, hsbk.Brightness, hsbk.Kelvin, time.Second, enums.LightWaveformLIGHTWAVEFORMPULSE)
+	if p, ok := msg.Payload.(*packets.LightSetWaveformOptional); ok {
+		p.Transient = true
+	}
+	return msg
+}
+
 func matchGesture(h Hand, g config.Gesture) bool {
 	return h.Gesture != "" && h.Gesture == g
 }
@@ -2,13 +2,17 @@ package consumer
 
 import (
 	"log/slog"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/alessio-palumbo/lifx-force/internal/config"
 	"github.com/alessio-palumbo/lifx-force/internal/logger"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/messages"
 	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/alessio-palumbo/lifxprotocol-go/gen/protocol/enums"
+	"github.com/alessio-palumbo/lifxprotocol-go/gen/protocol/packets"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,7 +58,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOn()},
+				serial0: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"gesture event with all target": {
@@ -70,10 +74,10 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOn()},
-				serial1: {messages.SetPowerOn()},
-				serial2: {messages.SetPowerOn()},
-				serial3: {messages.SetPowerOn()},
+				serial0: {setLightPower(true, time.Millisecond)},
+				serial1: {setLightPower(true, time.Millisecond)},
+				serial2: {setLightPower(true, time.Millisecond)},
+				serial3: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"gesture event with label target": {
@@ -89,7 +93,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"gesture event with group target": {
@@ -105,8 +109,8 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial1: {messages.SetPowerOff()},
-				serial2: {messages.SetPowerOff()},
+				serial1: {setLightPower(false, time.Millisecond)},
+				serial2: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"gesture event with location target": {
@@ -122,9 +126,9 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial1: {messages.SetPowerOn()},
-				serial2: {messages.SetPowerOn()},
-				serial3: {messages.SetPowerOn()},
+				serial1: {setLightPower(true, time.Millisecond)},
+				serial2: {setLightPower(true, time.Millisecond)},
+				serial3: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"finger event with selector serial": {
@@ -140,7 +144,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOn()},
+				serial0: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"finger event with all target": {
@@ -156,10 +160,10 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOn()},
-				serial1: {messages.SetPowerOn()},
-				serial2: {messages.SetPowerOn()},
-				serial3: {messages.SetPowerOn()},
+				serial0: {setLightPower(true, time.Millisecond)},
+				serial1: {setLightPower(true, time.Millisecond)},
+				serial2: {setLightPower(true, time.Millisecond)},
+				serial3: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"finger event with label target": {
@@ -175,7 +179,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"finger event with group target": {
@@ -191,8 +195,8 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial1: {messages.SetPowerOff()},
-				serial2: {messages.SetPowerOff()},
+				serial1: {setLightPower(false, time.Millisecond)},
+				serial2: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"finger event with location target": {
@@ -208,9 +212,9 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial1: {messages.SetPowerOn()},
-				serial2: {messages.SetPowerOn()},
-				serial3: {messages.SetPowerOn()},
+				serial1: {setLightPower(true, time.Millisecond)},
+				serial2: {setLightPower(true, time.Millisecond)},
+				serial3: {setLightPower(true, time.Millisecond)},
 			},
 		},
 		"ignores fingers when gesture is available": {
@@ -231,9 +235,43 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Fingers: config.FingerPattern{1, 1, 1, 1, 1}, Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial1: {messages.SetPowerOff()},
-				serial2: {messages.SetPowerOff()},
-				serial3: {messages.SetPowerOff()},
+				serial1: {setLightPower(false, time.Millisecond)},
+				serial2: {setLightPower(false, time.Millisecond)},
+				serial3: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"binding transition overrides general": {
+			cfg: &config.Config{
+				General: config.General{TransitionMs: defaultMs},
+				Bindings: []config.Binding{
+					{
+						Gesture:      config.GestureSwipeLeft,
+						Action:       "power_off",
+						Selector:     config.Selector{Type: config.SelectorTypeSerial, Serial: serial0},
+						TransitionMs: 2000,
+					},
+				},
+			},
+			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeLeft}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, 2*time.Second)},
+			},
+		},
+		"power on sends full level with binding transition": {
+			cfg: &config.Config{
+				General: config.General{TransitionMs: defaultMs},
+				Bindings: []config.Binding{
+					{
+						Gesture:      config.GestureSwipeRight,
+						Action:       "power_on",
+						Selector:     config.Selector{Type: config.SelectorTypeSerial, Serial: serial0},
+						TransitionMs: 2000,
+					},
+				},
+			},
+			event: &Event{Hands: []Hand{{Gesture: config.GestureSwipeRight}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {protocol.NewMessage(&packets.LightSetPower{Level: 65535, Duration: 2000})},
 			},
 		},
 		"does nothing with no bindings": {
@@ -255,7 +293,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft}, {Label: RightHandLabel, Gesture: config.GestureSwipeRight}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"compound gesture event: contract": {
@@ -271,7 +309,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeRight}, {Label: RightHandLabel, Gesture: config.GestureSwipeLeft}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"compound gesture event: push_down": {
@@ -287,7 +325,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeDown}, {Label: RightHandLabel, Gesture: config.GestureSwipeDown}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 		"compound gesture event: pull_up": {
@@ -303,7 +341,7 @@ func TestConsumer(t *testing.T) {
 			},
 			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeUp}, {Label: RightHandLabel, Gesture: config.GestureSwipeUp}}},
 			wantMessages: map[device.Serial][]*protocol.Message{
-				serial0: {messages.SetPowerOff()},
+				serial0: {setLightPower(false, time.Millisecond)},
 			},
 		},
 	}
@@ -318,12 +356,670 @@ func TestConsumer(t *testing.T) {
 	}
 }
 
+func TestConsumerStableFrames(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		openHand   = config.FingerPattern{1, 1, 1, 1, 1}
+		fist       = config.FingerPattern{0, 0, 0, 0, 0}
+		cfg        = &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Tracking: config.Tracking{StableFrames: 3},
+			Bindings: []config.Binding{
+				{
+					Pattern:  &openHand,
+					Action:   "power_on",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Pattern:  &fist,
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+			},
+		}
+	)
+	hand := func(l label, p config.FingerPattern) Hand { return Hand{Label: l, Fingers: p} }
+
+	testCases := map[string]struct {
+		events       []*Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"flickering pattern never fires": {
+			events: []*Event{
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, fist)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, fist)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+			},
+		},
+		"fires once the pattern is stable": {
+			events: []*Event{
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, fist)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"history is tracked per hand": {
+			events: []*Event{
+				{Hands: []Hand{hand(LeftHandLabel, openHand), hand(RightHandLabel, fist)}},
+				{Hands: []Hand{hand(LeftHandLabel, fist), hand(RightHandLabel, fist)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand), hand(RightHandLabel, fist)}},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"history resets when the hand disappears": {
+			events: []*Event{
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+				{Hands: []Hand{hand(LeftHandLabel, openHand)}},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			for _, e := range tc.events {
+				c.HandleEvent(e)
+			}
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerCount(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		two        = 2
+		three      = 3
+		peace      = config.FingerPattern{0, 1, 1, 0, 0}
+		dim        = 20.0
+		cfg        = &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Count:    &two,
+					Action:   "power_on",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Count:    &two,
+					Hand:     "right",
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Count:    &three,
+					Hand:     "left",
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Pattern:  &peace,
+					Action:   "set_color",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+					HSBK:     &config.HSBK{Brightness: &dim},
+				},
+			},
+		}
+	)
+	hand := func(l label, p config.FingerPattern) *Event {
+		return &Event{Hands: []Hand{{Label: l, Fingers: p}}}
+	}
+
+	testCases := map[string]struct {
+		event        *Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"count matches any pattern": {
+			event: hand(LeftHandLabel, config.FingerPattern{1, 0, 0, 0, 1}),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"hand specific count takes precedence": {
+			event: hand(RightHandLabel, config.FingerPattern{1, 0, 0, 0, 1}),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"hand specific count on the matching hand": {
+			event: hand(LeftHandLabel, config.FingerPattern{1, 1, 1, 0, 0}),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"hand specific count ignored on the other hand": {
+			event: hand(RightHandLabel, config.FingerPattern{1, 1, 1, 0, 0}),
+		},
+		"exact pattern takes precedence over count": {
+			event: hand(RightHandLabel, peace),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {messages.SetColor(nil, nil, &dim, nil, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW)},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerTolerance(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		openHand   = config.FingerPattern{1, 1, 1, 1, 1}
+		peace      = config.FingerPattern{0, 1, 1, 0, 0}
+		rock       = config.FingerPattern{0, 1, 0, 0, 1}
+		cfg        = &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Pattern:   &openHand,
+					Tolerance: 1,
+					Action:    "power_on",
+					Selector:  config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Pattern:   &peace,
+					Tolerance: 2,
+					Action:    "power_off",
+					Selector:  config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Pattern:   &rock,
+					Tolerance: 1,
+					Action:    "power_off",
+					Selector:  config.Selector{Type: config.SelectorTypeAll},
+				},
+			},
+		}
+	)
+	hand := func(p config.FingerPattern) *Event {
+		return &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: p}}}
+	}
+
+	testCases := map[string]struct {
+		event        *Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"exact pattern": {
+			event: hand(openHand),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"one finger off": {
+			event: hand(config.FingerPattern{1, 0, 1, 1, 1}),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"exact pattern wins over close ones": {
+			event: hand(peace),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"closest pattern wins": {
+			event: hand(config.FingerPattern{0, 1, 1, 1, 1}),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"beyond tolerance": {
+			event: hand(config.FingerPattern{0, 0, 0, 1, 1}),
+		},
+		"tie fires nothing": {
+			event: hand(config.FingerPattern{0, 1, 1, 0, 1}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerFallback(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		red        = 0.0
+		saturated  = 100.0
+		blink      = &config.HSBK{Hue: &red, Saturation: &saturated}
+		openHand   = config.FingerPattern{1, 1, 1, 1, 1}
+		cfg        = &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Gesture:  config.GestureSwipeUp,
+					Action:   "power_on",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Pattern:  &openHand,
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Fallback: true,
+					Action:   config.ActionFlash,
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+					HSBK:     blink,
+				},
+			},
+		}
+	)
+
+	testCases := map[string]struct {
+		event        *Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"matched gesture": {
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeUp}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"unmatched gesture runs the fallback": {
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {flashMessage(blink)},
+			},
+		},
+		"unmatched gesture with a matched pattern runs the pattern": {
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft, Fingers: openHand}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"unmatched compound gesture runs the fallback once": {
+			event: &Event{Hands: []Hand{
+				{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft},
+				{Label: RightHandLabel, Gesture: config.GestureSwipeRight},
+			}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {flashMessage(blink)},
+			},
+		},
+		"unmatched pattern does not run the fallback": {
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}}}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerPresence(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		start      = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		cfg        = &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Presence: config.PresenceEnter,
+					Action:   "power_on",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+				{
+					Presence: config.PresenceLeave,
+					AfterMs:  10000,
+					Action:   "power_off",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+				},
+			},
+		}
+		present = &Event{Hands: []Hand{{Label: LeftHandLabel}}}
+		absent  = &Event{}
+	)
+
+	// A step without event checks presence as if no events arrived,
+	// and one with togglePause pauses or resumes the consumer.
+	type step struct {
+		offset      time.Duration
+		event       *Event
+		togglePause bool
+	}
+	testCases := map[string]struct {
+		steps        []step
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"no hands on start does not trigger leave": {
+			steps: []step{{offset: 0, event: absent}, {offset: time.Minute, event: absent}},
+		},
+		"enter fires once": {
+			steps: []step{{offset: 0, event: absent}, {offset: time.Second, event: present}, {offset: 2 * time.Second, event: present}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"leave waits for dwell": {
+			steps: []step{{offset: 0, event: present}, {offset: time.Second, event: absent}, {offset: 5 * time.Second, event: absent}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"leave fires after dwell": {
+			steps: []step{{offset: 0, event: present}, {offset: time.Second, event: absent}, {offset: 11 * time.Second, event: absent}, {offset: 12 * time.Second, event: absent}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond), setLightPower(false, time.Millisecond)},
+			},
+		},
+		"hands returning reset the leave dwell": {
+			steps: []step{{offset: 0, event: present}, {offset: time.Second, event: absent}, {offset: 8 * time.Second, event: present}, {offset: 9 * time.Second, event: absent}, {offset: 15 * time.Second, event: absent}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond), setLightPower(true, time.Millisecond)},
+			},
+		},
+		"no hands on start does not trigger leave without events": {
+			steps: []step{{offset: 0}, {offset: time.Minute}},
+		},
+		"leave waits for dwell without events": {
+			steps: []step{{offset: 0, event: present}, {offset: 5 * time.Second}, {offset: 9 * time.Second}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"leave fires after dwell without events": {
+			steps: []step{{offset: 0, event: present}, {offset: 5 * time.Second}, {offset: 10 * time.Second}, {offset: 11 * time.Second}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond), setLightPower(false, time.Millisecond)},
+			},
+		},
+		"leave does not fire while paused": {
+			steps: []step{{offset: 0, event: present}, {offset: time.Second, togglePause: true}, {offset: time.Minute}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"leave dwell restarts on resume": {
+			steps: []step{
+				{offset: 0, event: present}, {offset: time.Second, togglePause: true},
+				{offset: time.Minute, togglePause: true}, {offset: time.Minute + 5*time.Second},
+				{offset: time.Minute + 9*time.Second},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"leave fires after dwell from resume": {
+			steps: []step{
+				{offset: 0, event: present}, {offset: time.Second, togglePause: true},
+				{offset: time.Minute, togglePause: true}, {offset: time.Minute + 5*time.Second},
+				{offset: time.Minute + 9*time.Second}, {offset: time.Minute + 10*time.Second},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond), setLightPower(false, time.Millisecond)},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			for _, s := range tc.steps {
+				c.now = func() time.Time { return start.Add(s.offset) }
+				switch {
+				case s.togglePause:
+					c.TogglePause()
+				case s.event != nil:
+					c.HandleEvent(s.event)
+				default:
+					c.check()
+				}
+			}
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerOrientation(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		devices    = []device.Device{{Serial: serial0}, {Serial: serial1}}
+		bindings   = []config.Binding{
+			{
+				Gesture:  config.GestureSwipeLeft,
+				Action:   "power_on",
+				Selector: config.Selector{Type: config.SelectorTypeSerial, Serial: serial0},
+			},
+			{
+				Gesture:  config.GestureSwipeUp,
+				Action:   "power_on",
+				Selector: config.Selector{Type: config.SelectorTypeSerial, Serial: serial1},
+			},
+			{
+				Gesture:  config.GestureExpand,
+				Action:   "power_off",
+				Selector: config.Selector{Type: config.SelectorTypeAll},
+			},
+		}
+	)
+	testCases := map[string]struct {
+		tracking     config.Tracking
+		event        *Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"mirror swaps horizontal swipes": {
+			tracking: config.Tracking{MirrorHorizontal: true},
+			event:    &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeRight}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"mirror does not affect vertical swipes": {
+			tracking: config.Tracking{MirrorHorizontal: true},
+			event:    &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeUp}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"mirror keeps compound gestures intuitive": {
+			tracking: config.Tracking{MirrorHorizontal: true},
+			event:    &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeLeft}, {Label: RightHandLabel, Gesture: config.GestureSwipeRight}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {setLightPower(false, time.Millisecond)},
+				serial1: {setLightPower(false, time.Millisecond)},
+			},
+		},
+		"flip swaps vertical swipes": {
+			tracking: config.Tracking{FlipVertical: true},
+			event:    &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeDown}}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {setLightPower(true, time.Millisecond)},
+			},
+		},
+		"flip does not affect horizontal swipes": {
+			tracking: config.Tracking{FlipVertical: true},
+			event:    &Event{Hands: []Hand{{Label: LeftHandLabel, Gesture: config.GestureSwipeRight}}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Tracking: tc.tracking, Bindings: bindings}
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerWhen(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		warm       = uint16(2700)
+		daylight   = uint16(5500)
+		dim        = 20.0
+		bright     = 100.0
+		day        = func(h int) time.Time { return time.Date(2025, 1, 3, h, 0, 0, 0, time.Local) }
+		cfg        = &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Gesture:  config.GestureSwipeUp,
+					Action:   "set_color",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+					HSBK:     &config.HSBK{Brightness: &dim, Kelvin: &warm},
+					When:     &config.When{From: "22:00", To: "06:00"},
+				},
+				{
+					Gesture:  config.GestureSwipeUp,
+					Action:   "set_color",
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+					HSBK:     &config.HSBK{Brightness: &bright, Kelvin: &daylight},
+					When:     &config.When{From: "06:00", To: "12:00"},
+				},
+			},
+		}
+		event = &Event{Hands: []Hand{{Gesture: config.GestureSwipeUp}}}
+	)
+
+	testCases := map[string]struct {
+		now          time.Time
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"night binding": {
+			now: day(23),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {messages.SetColor(nil, nil, &dim, &warm, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW)},
+			},
+		},
+		"night binding after midnight": {
+			now: day(2),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {messages.SetColor(nil, nil, &dim, &warm, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW)},
+			},
+		},
+		"morning binding": {
+			now: day(8),
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {messages.SetColor(nil, nil, &bright, &daylight, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW)},
+			},
+		},
+		"no active binding": {
+			now: day(15),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.now = func() time.Time { return tc.now }
+			c.HandleEvent(event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerCondition(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		half       = 50.0
+		warm       = uint16(2700)
+		bindings   = []config.Binding{
+			{
+				Gesture:  config.GestureSwipeUp,
+				If:       &config.Condition{Power: "off"},
+				Action:   "power_on",
+				HSBK:     &config.HSBK{Brightness: &half},
+				Selector: config.Selector{Type: config.SelectorTypeGroup, Value: "Bedroom"},
+				Else:     &config.Else{Action: "set_color", HSBK: &config.HSBK{Kelvin: &warm}},
+			},
+		}
+		event     = &Event{Hands: []Hand{{Gesture: config.GestureSwipeUp}}}
+		powerOn   = []*protocol.Message{messages.SetColor(nil, nil, &half, nil, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW), setLightPower(true, time.Millisecond)}
+		warmWhite = []*protocol.Message{messages.SetColor(nil, nil, nil, &warm, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW)}
+	)
+
+	testCases := map[string]struct {
+		devices      []device.Device
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"condition true": {
+			devices: []device.Device{
+				{Serial: serial0, Group: "Bedroom"},
+				{Serial: serial1, Group: "Bedroom"},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: powerOn,
+				serial1: powerOn,
+			},
+		},
+		"condition false runs else": {
+			devices: []device.Device{
+				{Serial: serial0, Group: "Bedroom"},
+				{Serial: serial1, Group: "Bedroom", PoweredOn: true},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: warmWhite,
+				serial1: warmWhite,
+			},
+		},
+		"no targets runs else": {
+			devices: []device.Device{{Serial: serial0, Group: "Patio"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Bindings: bindings}
+			ctrl := &mockController{devices: tc.devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.HandleEvent(event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
 type mockController struct {
+	mu       sync.Mutex
 	devices  []device.Device
 	messages map[device.Serial][]*protocol.Message
 }
 
 func (m *mockController) Send(serial device.Serial, msg *protocol.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.messages == nil {
 		m.messages = make(map[device.Serial][]*protocol.Message)
 	}
@@ -333,3 +1029,14 @@ func (m *mockController) Send(serial device.Serial, msg *protocol.Message) error
 func (m *mockController) GetDevices() []device.Device {
 	return m.devices
 }
+
+// sent returns the number of messages sent to all devices.
+func (m *mockController) sent() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for _, msgs := range m.messages {
+		n += len(msgs)
+	}
+	return n
+}
@@ -0,0 +1,131 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+const (
+	// defaultExecTimeout is used when an exec binding does not set a timeout.
+	defaultExecTimeout = 10 * time.Second
+	// execWaitDelay bounds the wait for the output of a terminated command,
+	// which its child processes may keep open.
+	execWaitDelay = time.Second
+)
+
+// executor runs the jobs of exec and webhook bindings asynchronously,
+// so that event handling never blocks on a child process or request.
+type executor struct {
+	logger *slog.Logger
+	// sem caps the number of jobs running concurrently.
+	sem    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newExecutor(maxConcurrent int, logger *slog.Logger) *executor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &executor{
+		logger: logger,
+		sem:    make(chan struct{}, maxConcurrent),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// start runs the given job in the background. It fails without
+// running the job when the concurrency cap is reached.
+func (e *executor) start(job func(ctx context.Context)) error {
+	select {
+	case e.sem <- struct{}{}:
+	default:
+		return fmt.Errorf("too many running jobs, max %d", cap(e.sem))
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer func() { <-e.sem }()
+		job(e.ctx)
+	}()
+	return nil
+}
+
+// execFunc returns a sendFunc starting the given command in the background.
+func (e *executor) execFunc(x *config.Exec) sendFunc {
+	return func(_ lanController, t trigger) error {
+		return e.start(func(ctx context.Context) { e.run(ctx, x, t) })
+	}
+}
+
+func (e *executor) run(ctx context.Context, x *config.Exec, t trigger) {
+	timeout := defaultExecTimeout
+	if x.TimeoutMs > 0 {
+		timeout = time.Duration(x.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, x.Command, expandArgs(x.Args, t)...)
+	cmd.Env = allowedEnv(x.Env)
+	cmd.WaitDelay = execWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	attrs := []any{
+		slog.String("command", x.Command),
+		slog.String("stdout", strings.TrimSpace(stdout.String())),
+		slog.String("stderr", strings.TrimSpace(stderr.String())),
+	}
+	if err != nil {
+		e.logger.Warn("command failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	e.logger.Info("command completed", attrs...)
+}
+
+// close terminates the running jobs and waits for them to exit.
+func (e *executor) close() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// expandArgs replaces the trigger placeholders in the given arguments.
+func expandArgs(args []string, t trigger) []string {
+	r := strings.NewReplacer(
+		"{gesture}", string(t.Gesture),
+		"{hand}", string(t.Hand),
+		"{fingers}", t.Fingers.String(),
+		"{presence}", string(t.Presence),
+	)
+	expanded := make([]string, len(args))
+	for i, a := range args {
+		expanded[i] = r.Replace(a)
+	}
+	return expanded
+}
+
+// allowedEnv returns the environment variables of the process whose names
+// are in the allowlist. It is never nil, so that commands do not inherit
+// the whole environment.
+func allowedEnv(names []string) []string {
+	env := []string{}
+	for _, n := range names {
+		if v, ok := os.LookupEnv(n); ok {
+			env = append(env, n+"="+v)
+		}
+	}
+	return env
+}
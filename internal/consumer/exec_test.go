@@ -0,0 +1,114 @@
+package consumer
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandArgs(t *testing.T) {
+	tr := trigger{Gesture: config.GestureSwipeLeft, Hand: LeftHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}}
+	got := expandArgs([]string{"--gesture={gesture}", "{hand}", "{fingers}", "{presence}", "plain"}, tr)
+	assert.Equal(t, []string{"--gesture=swipe_left", "left", "01100", "", "plain"}, got)
+}
+
+func TestConsumerExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	t.Run("runs command with placeholders and allowed env", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		t.Setenv("LIFX_FORCE_ALLOWED", "yes")
+		t.Setenv("LIFX_FORCE_DENIED", "no")
+		cfg := &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{
+					Gesture: config.GestureSwipeLeft,
+					Action:  config.ActionExec,
+					Exec: &config.Exec{
+						Command: "sh",
+						Args:    []string{"-c", `echo "{gesture} {hand} $LIFX_FORCE_ALLOWED $LIFX_FORCE_DENIED" > ` + out},
+						Env:     []string{"LIFX_FORCE_ALLOWED"},
+					},
+				},
+			},
+		}
+		c := New(cfg, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+		c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeLeft}}})
+		c.executor.wg.Wait()
+
+		data, err := os.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "swipe_left right yes \n", string(data))
+	})
+
+	t.Run("does not block and caps concurrency", func(t *testing.T) {
+		e := newExecutor(1, logger.NewLogger(slog.LevelInfo, ""))
+		f := e.execFunc(&config.Exec{Command: "sleep", Args: []string{"5"}, TimeoutMs: 5000})
+
+		start := time.Now()
+		assert.NoError(t, f(nil, trigger{}))
+		assert.EqualError(t, f(nil, trigger{}), "too many running jobs, max 1")
+		assert.Less(t, time.Since(start), time.Second)
+
+		// Close terminates the running command.
+		e.close()
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("terminates command on timeout", func(t *testing.T) {
+		e := newExecutor(1, logger.NewLogger(slog.LevelInfo, ""))
+		f := e.execFunc(&config.Exec{Command: "sleep", Args: []string{"5"}, TimeoutMs: 50})
+
+		start := time.Now()
+		assert.NoError(t, f(nil, trigger{}))
+		e.wg.Wait()
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("does not wait for child processes holding the output", func(t *testing.T) {
+		e := newExecutor(1, logger.NewLogger(slog.LevelInfo, ""))
+		f := e.execFunc(&config.Exec{Command: "sh", Args: []string{"-c", "sleep 5 & sleep 5"}, TimeoutMs: 50})
+
+		start := time.Now()
+		assert.NoError(t, f(nil, trigger{}))
+		e.wg.Wait()
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+
+	t.Run("held pattern runs once", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		peace := config.FingerPattern{0, 1, 1, 0, 0}
+		cfg := &config.Config{
+			General: config.General{TransitionMs: 1},
+			Exec:    config.ExecLimit{MaxConcurrent: 4},
+			Bindings: []config.Binding{
+				{
+					Pattern: &peace,
+					Action:  config.ActionExec,
+					Exec:    &config.Exec{Command: "sh", Args: []string{"-c", "echo {fingers} >> " + out}},
+				},
+			},
+		}
+		c := New(cfg, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+		for range 10 {
+			c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Fingers: peace}}})
+		}
+		c.executor.wg.Wait()
+
+		data, err := os.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "01100\n", string(data))
+	})
+}
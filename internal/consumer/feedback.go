@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"cmp"
+	"log/slog"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+)
+
+var (
+	green, red, saturated = 120.0, 0.0, 100.0
+
+	// feedbackHSBK and feedbackErrorHSBK are pulsed when the feedback colours are not set.
+	feedbackHSBK      = &config.HSBK{Hue: &green, Saturation: &saturated}
+	feedbackErrorHSBK = &config.HSBK{Hue: &red, Saturation: &saturated}
+)
+
+// bindingFeedback returns the feedback of the given binding, defaulting
+// to the global one, or nil when disabled. Dials are never pulsed as they
+// are actioned continuously.
+func (c *Consumer) bindingFeedback(b config.Binding) *config.Feedback {
+	if b.Action == config.ActionDial {
+		return nil
+	}
+	f := b.Feedback
+	if f == nil {
+		f = &c.cfg.Feedback
+	}
+	if !f.Enabled {
+		return nil
+	}
+	return f
+}
+
+// feedback pulses the feedback colour, or the error colour when actioning
+// a binding failed, on the feedback devices or else on the given serials.
+func (c *Consumer) feedback(f *config.Feedback, serials []device.Serial, err error) {
+	if f == nil {
+		return
+	}
+	hsbk := cmp.Or(f.HSBK, feedbackHSBK)
+	if err != nil {
+		hsbk = cmp.Or(f.ErrorHSBK, feedbackErrorHSBK)
+	}
+	if f.Selector != nil {
+		c.flash(*f.Selector, hsbk)
+		return
+	}
+	if err := sendMultiple(c.ctrl, serials, flashMessage(hsbk)); err != nil {
+		c.logger.Debug("failed to send feedback", slog.Any("error", err))
+	}
+}
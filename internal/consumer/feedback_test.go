@@ -0,0 +1,143 @@
+package consumer
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// unreachableController fails to send to the unreachable device.
+type unreachableController struct {
+	mockController
+	unreachable device.Serial
+}
+
+func (u *unreachableController) Send(serial device.Serial, msg *protocol.Message) error {
+	if serial == u.unreachable {
+		return errors.New("send failed")
+	}
+	return u.mockController.Send(serial, msg)
+}
+
+func TestConsumerFeedback(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		serial2, _ = device.SerialFromHex("d073d5000002")
+		devices    = []device.Device{{Serial: serial0, Group: "Bedroom"}, {Serial: serial1, Label: "indicator"}, {Serial: serial2, Group: "Bedroom"}}
+		bedroom    = config.Selector{Type: config.SelectorTypeGroup, Value: "Bedroom"}
+		indicator  = &config.Selector{Type: config.SelectorTypeLabel, Value: "indicator"}
+		blue       = 240.0
+		blueHSBK   = &config.HSBK{Hue: &blue}
+		on         = setLightPower(true, time.Millisecond)
+		swipe      = &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeUp}}}
+	)
+
+	testCases := map[string]struct {
+		feedback        config.Feedback
+		bindingFeedback *config.Feedback
+		unreachable     device.Serial
+		wantMessages    map[device.Serial][]*protocol.Message
+	}{
+		"disabled": {
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {on},
+				serial2: {on},
+			},
+		},
+		"pulses targets": {
+			feedback: config.Feedback{Enabled: true},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {on, flashMessage(feedbackHSBK)},
+				serial2: {on, flashMessage(feedbackHSBK)},
+			},
+		},
+		"pulses indicator": {
+			feedback: config.Feedback{Enabled: true, Selector: indicator, HSBK: blueHSBK},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {on},
+				serial1: {flashMessage(blueHSBK)},
+				serial2: {on},
+			},
+		},
+		"pulses error colour on failure": {
+			feedback:    config.Feedback{Enabled: true, Selector: indicator},
+			unreachable: serial0,
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(feedbackErrorHSBK)},
+			},
+		},
+		"binding overrides global feedback": {
+			feedback:        config.Feedback{Enabled: true},
+			bindingFeedback: &config.Feedback{Enabled: true, Selector: indicator, ErrorHSBK: blueHSBK},
+			unreachable:     serial0,
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial1: {flashMessage(blueHSBK)},
+			},
+		},
+		"binding disables global feedback": {
+			feedback:        config.Feedback{Enabled: true},
+			bindingFeedback: &config.Feedback{},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {on},
+				serial2: {on},
+			},
+		},
+		"binding enables feedback": {
+			bindingFeedback: &config.Feedback{Enabled: true, Selector: indicator},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {on},
+				serial1: {flashMessage(feedbackHSBK)},
+				serial2: {on},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{
+				General:  config.General{TransitionMs: 1},
+				Feedback: tc.feedback,
+				Bindings: []config.Binding{{Gesture: config.GestureSwipeUp, Action: config.ActionPowerOn, Selector: bedroom, Feedback: tc.bindingFeedback}},
+			}
+			ctrl := &unreachableController{mockController: mockController{devices: devices}, unreachable: tc.unreachable}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			defer c.Close()
+
+			c.HandleEvent(swipe)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
+
+func TestConsumerFeedbackHeldPattern(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		open       = config.FingerPattern{1, 1, 1, 1, 1}
+		fist       = config.FingerPattern{0, 0, 0, 0, 0}
+		on         = setLightPower(true, time.Millisecond)
+		cfg        = &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Feedback: config.Feedback{Enabled: true},
+			Bindings: []config.Binding{{Pattern: &open, Action: config.ActionPowerOn, Selector: config.Selector{Type: config.SelectorTypeAll}}},
+		}
+		ctrl = &mockController{devices: []device.Device{{Serial: serial0}}}
+	)
+	c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+	defer c.Close()
+
+	for _, p := range []config.FingerPattern{open, open, open, fist, open, open} {
+		c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Fingers: p}}})
+	}
+	// The pulse is only sent when the pattern starts matching.
+	assert.Equal(t, map[device.Serial][]*protocol.Message{
+		serial0: {on, flashMessage(feedbackHSBK), on, flashMessage(feedbackHSBK)},
+	}, ctrl.messages)
+}
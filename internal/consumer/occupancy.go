@@ -0,0 +1,149 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+)
+
+// occupancyCheckPeriod is how often inactivity is checked while no events arrive.
+const occupancyCheckPeriod = time.Second
+
+// Occupancy treats fingertrack as a presence sensor. When no hands are seen
+// for the configured idle timeout, the selected devices that are on are faded
+// off, or to the configured colour, and their previous state is restored once
+// hands reappear.
+type Occupancy struct {
+	cfg    *config.Config
+	ctrl   lanController
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	lastSeen time.Time
+	idle     bool
+	// paused stops devices from being turned off or restored.
+	paused bool
+	// saved holds the state of the devices actioned on idle.
+	saved []device.Device
+}
+
+func NewOccupancy(cfg *config.Config, ctrl lanController, logger *slog.Logger) *Occupancy {
+	o := &Occupancy{
+		cfg:    cfg,
+		ctrl:   ctrl,
+		logger: logger,
+		now:    time.Now,
+	}
+	o.lastSeen = o.now()
+	return o
+}
+
+// Observe records the given event, restoring the devices actioned
+// for inactivity when hands are detected.
+func (o *Occupancy) Observe(event *Event) {
+	if len(event.Hands) == 0 {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.lastSeen = o.now()
+	if o.idle && !o.paused {
+		o.idle = false
+		o.restore()
+	}
+}
+
+// SetPaused pauses or resumes the monitor along with the consumer.
+// The idle timeout restarts when resumed, as events may not have been
+// received while paused.
+func (o *Occupancy) SetPaused(paused bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.paused = paused
+	if !paused {
+		o.lastSeen = o.now()
+	}
+}
+
+// Run periodically checks for inactivity until the context is done,
+// so that devices are turned off even when no events arrive.
+func (o *Occupancy) Run(ctx context.Context) {
+	ticker := time.NewTicker(occupancyCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.check()
+		}
+	}
+}
+
+// check runs the idle action on the selected devices if no hands were seen for the idle timeout.
+func (o *Occupancy) check() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	timeout := time.Duration(o.cfg.Occupancy.IdleTimeoutMs) * time.Millisecond
+	if o.paused || o.idle || o.now().Sub(o.lastSeen) < timeout {
+		return
+	}
+	o.idle = true
+	o.saved = nil
+
+	cond := selectorCondition(o.cfg.Occupancy.Selector)
+	if cond == nil {
+		return
+	}
+	for _, d := range o.ctrl.GetDevices() {
+		if d.PoweredOn && cond(&d) {
+			o.saved = append(o.saved, d)
+		}
+	}
+
+	o.logger.Info("no hands detected, actioning devices", slog.Any("action", o.cfg.Occupancy.Action), slog.Int("devices", len(o.saved)))
+	msg := idleMessage(&o.cfg.Occupancy)
+	for _, d := range o.saved {
+		if err := o.ctrl.Send(d.Serial, msg); err != nil {
+			o.logger.Warn("failed to action device", slog.Any("serial", d.Serial), slog.Any("error", err))
+		}
+	}
+}
+
+// idleMessage returns the message of the idle action, turning the devices off by default.
+func idleMessage(occupancy *config.Occupancy) *protocol.Message {
+	if occupancy.Action == config.ActionPowerSetColor {
+		return setColor(occupancy.HSBK, occupancy.Fade())
+	}
+	return setLightPower(false, occupancy.Fade())
+}
+
+// restore sets the saved devices back to their color and turns them on.
+func (o *Occupancy) restore() {
+	o.logger.Info("hands detected, restoring devices", slog.Int("devices", len(o.saved)))
+	d := transition(o.cfg, 0)
+	for _, dev := range o.saved {
+		c := dev.Color
+		msgs := []*protocol.Message{
+			setColor(&config.HSBK{Hue: &c.Hue, Saturation: &c.Saturation, Brightness: &c.Brightness, Kelvin: &c.Kelvin}, d),
+			setLightPower(true, d),
+		}
+		for _, msg := range msgs {
+			if err := o.ctrl.Send(dev.Serial, msg); err != nil {
+				o.logger.Warn("failed to restore device", slog.Any("serial", dev.Serial), slog.Any("error", err))
+				break
+			}
+		}
+	}
+	o.saved = nil
+}
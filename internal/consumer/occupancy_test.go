@@ -0,0 +1,127 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/messages"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/alessio-palumbo/lifxprotocol-go/gen/protocol/enums"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOccupancy(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		serial2, _ = device.SerialFromHex("d073d5000002")
+		color0     = device.Color{Hue: 120, Saturation: 100, Brightness: 50, Kelvin: 3500}
+		devices    = []device.Device{
+			{Serial: serial0, Group: "Bedroom", PoweredOn: true, Color: color0},
+			{Serial: serial1, Group: "Bedroom", PoweredOn: false},
+			{Serial: serial2, Group: "Patio", PoweredOn: true},
+		}
+		start     = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		fadeMs    = 5000
+		dim       = 10.0
+		occupancy = config.Occupancy{
+			Enabled:       true,
+			IdleTimeoutMs: 60000,
+			FadeMs:        &fadeMs,
+			Selector:      config.Selector{Type: config.SelectorTypeGroup, Value: "Bedroom"},
+		}
+		present = &Event{Hands: []Hand{{Label: LeftHandLabel}}}
+		off     = setLightPower(false, 5*time.Second)
+		restore = []*protocol.Message{
+			messages.SetColor(&color0.Hue, &color0.Saturation, &color0.Brightness, &color0.Kelvin, time.Millisecond, enums.LightWaveformLIGHTWAVEFORMSAW),
+			setLightPower(true, time.Millisecond),
+		}
+	)
+
+	type step struct {
+		offset time.Duration
+		event  *Event
+		pause  bool
+		resume bool
+	}
+	testCases := map[string]struct {
+		action       config.Action
+		hsbk         *config.HSBK
+		steps        []step
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"stays on before idle timeout": {
+			steps: []step{{offset: 30 * time.Second}, {offset: 50 * time.Second, event: &Event{}}, {offset: 59 * time.Second}},
+		},
+		"turns off powered on devices after idle timeout": {
+			steps: []step{{offset: time.Minute}, {offset: 2 * time.Minute}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {off},
+			},
+		},
+		"dims devices with set_color action": {
+			action: config.ActionPowerSetColor,
+			hsbk:   &config.HSBK{Brightness: &dim},
+			steps:  []step{{offset: time.Minute}, {offset: 2 * time.Minute, event: present}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: append([]*protocol.Message{
+					messages.SetColor(nil, nil, &dim, nil, 5*time.Second, enums.LightWaveformLIGHTWAVEFORMSAW),
+				}, restore...),
+			},
+		},
+		"hands reset the idle timeout": {
+			steps: []step{{offset: 50 * time.Second, event: present}, {offset: 100 * time.Second}},
+		},
+		"restores devices when hands reappear": {
+			steps: []step{{offset: time.Minute}, {offset: 2 * time.Minute, event: present}, {offset: 2*time.Minute + time.Second, event: present}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: append([]*protocol.Message{off}, restore...),
+			},
+		},
+		"stays on while paused": {
+			steps: []step{{offset: 10 * time.Second, pause: true}, {offset: 2 * time.Minute}},
+		},
+		"restarts idle timeout when resumed": {
+			steps: []step{
+				{offset: 10 * time.Second, pause: true},
+				{offset: 2 * time.Minute, resume: true},
+				{offset: 2*time.Minute + 59*time.Second},
+				{offset: 3 * time.Minute},
+			},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {off},
+			},
+		},
+		"does not restore devices while paused": {
+			steps: []step{{offset: time.Minute}, {offset: 2 * time.Minute, pause: true}, {offset: 3 * time.Minute, event: present}},
+			wantMessages: map[device.Serial][]*protocol.Message{
+				serial0: {off},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Occupancy: occupancy}
+			cfg.Occupancy.Action, cfg.Occupancy.HSBK = tc.action, tc.hsbk
+			ctrl := &mockController{devices: devices}
+			o := NewOccupancy(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			o.lastSeen = start
+			for _, s := range tc.steps {
+				o.now = func() time.Time { return start.Add(s.offset) }
+				if s.pause || s.resume {
+					o.SetPaused(s.pause)
+				}
+				if s.event != nil {
+					o.Observe(s.event)
+				}
+				o.check()
+			}
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+		})
+	}
+}
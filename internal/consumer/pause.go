@@ -0,0 +1,85 @@
+package consumer
+
+// Pause stops actioning bindings until resumed, except those toggling pause.
+func (c *Consumer) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setPaused(true)
+}
+
+// Resume actions bindings again after being paused.
+func (c *Consumer) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setPaused(false)
+}
+
+// TogglePause pauses the consumer when active and resumes it when paused.
+func (c *Consumer) TogglePause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setPaused(!c.paused)
+}
+
+// SetPauseHandler sets a function called each time the consumer is paused or resumed.
+func (c *Consumer) SetPauseHandler(f func(paused bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onPause = f
+}
+
+func (c *Consumer) setPaused(paused bool) {
+	if c.paused == paused {
+		return
+	}
+	c.paused = paused
+	if paused {
+		c.logger.Info("paused")
+	} else {
+		c.logger.Info("resumed")
+		// Presence dwell times restart, as events are not handled while paused.
+		c.presentSince = c.now()
+	}
+	c.recordMode()
+	if c.onPause != nil {
+		c.onPause(paused)
+	}
+}
+
+// handlePaused only actions the bindings toggling pause, so that
+// the consumer can be resumed with a gesture or pattern.
+func (c *Consumer) handlePaused(hs map[label]Hand, hands []Hand) {
+	for g, match := range compoundGestures {
+		if match(hs) {
+			if b, ok := c.activeBinding(c.gestureBindings[g]); ok && b.togglesPause {
+				c.action(b, trigger{Gesture: g})
+				return
+			}
+		}
+	}
+	for _, h := range hands {
+		if h.Gesture != "" {
+			if b, ok := c.activeBinding(c.gestureBindings[h.Gesture]); ok && b.togglesPause {
+				c.action(b, trigger{Gesture: h.Gesture, Hand: h.Label, Fingers: h.Fingers, source: &h})
+				return
+			}
+		}
+		if c.isStable(h.Label) {
+			if b, ok := c.fingerBinding(h); ok && b.togglesPause {
+				c.actionFingers(b, h)
+				return
+			}
+			// Other patterns release the one toggling pause.
+			delete(c.held, h.Label)
+		}
+	}
+	c.logger.Debug("paused, ignoring event")
+}
+
+// togglePauseFunc returns a sendFunc toggling pause.
+func (c *Consumer) togglePauseFunc() sendFunc {
+	return func(lanController, trigger) error {
+		c.setPaused(!c.paused)
+		return nil
+	}
+}
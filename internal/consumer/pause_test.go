@@ -0,0 +1,104 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerPause(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		ctrl       = &mockController{devices: []device.Device{{Serial: serial0}}}
+		cfg        = &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Bindings: []config.Binding{{Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeAll}}},
+		}
+		swipe  = &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeDown}}}
+		paused []bool
+	)
+	c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+	c.SetPauseHandler(func(p bool) { paused = append(paused, p) })
+	defer c.Close()
+
+	c.Pause()
+	c.Pause()
+	assert.Equal(t, ModePaused, c.Status().Mode)
+	c.HandleEvent(swipe)
+	assert.Equal(t, 0, ctrl.sent())
+	assert.False(t, c.Status().LastEvent.IsZero())
+
+	c.Resume()
+	assert.Equal(t, ModeActive, c.Status().Mode)
+	c.HandleEvent(swipe)
+	assert.Equal(t, 1, ctrl.sent())
+
+	c.TogglePause()
+	assert.Equal(t, ModePaused, c.Status().Mode)
+	assert.Equal(t, []bool{true, false, true}, paused)
+}
+
+func TestConsumerTogglePause(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		all        = config.Selector{Type: config.SelectorTypeAll}
+		fist       = config.FingerPattern{0, 0, 0, 0, 0}
+		open       = config.FingerPattern{1, 1, 1, 1, 1}
+		bindings   = []config.Binding{
+			{Gesture: config.GestureSwipeUp, Action: config.ActionTogglePause},
+			{Pattern: &fist, Action: config.ActionTogglePause},
+			{Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: all},
+			{Pattern: &open, Action: config.ActionPowerOn, Selector: all},
+		}
+		swipeUp   = Hand{Label: RightHandLabel, Gesture: config.GestureSwipeUp, Fingers: open}
+		swipeDown = Hand{Label: RightHandLabel, Gesture: config.GestureSwipeDown, Fingers: open}
+		closed    = Hand{Label: RightHandLabel, Fingers: fist}
+		opened    = Hand{Label: RightHandLabel, Fingers: open}
+	)
+
+	testCases := map[string]struct {
+		events     [][]Hand
+		wantPaused bool
+		wantSent   int
+	}{
+		"gesture pauses": {
+			events:     [][]Hand{{swipeUp}, {swipeDown}},
+			wantPaused: true,
+		},
+		"gesture resumes": {
+			events:   [][]Hand{{swipeUp}, {swipeDown}, {swipeUp}, {swipeDown}},
+			wantSent: 1,
+		},
+		"held pattern toggles once": {
+			events:     [][]Hand{{closed}, {closed}, {closed}, {opened}},
+			wantPaused: true,
+		},
+		"released pattern toggles again": {
+			events:   [][]Hand{{closed}, {opened}, {closed}, {opened}},
+			wantSent: 1,
+		},
+		"pattern toggles again when the hand reappears": {
+			events:   [][]Hand{{closed}, {}, {closed}, {swipeDown}},
+			wantSent: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Bindings: bindings}
+			ctrl := &mockController{devices: []device.Device{{Serial: serial0}}}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			defer c.Close()
+
+			for _, hands := range tc.events {
+				c.HandleEvent(&Event{Hands: hands})
+			}
+			assert.Equal(t, tc.wantPaused, c.Status().Mode == ModePaused)
+			assert.Equal(t, tc.wantSent, ctrl.sent())
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package consumer
+
+import (
+	"slices"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+)
+
+// RecordType identifies the content of a Record.
+type RecordType string
+
+const (
+	// RecordTypeEvent is recorded for every event handled by the consumer.
+	RecordTypeEvent RecordType = "event"
+	// RecordTypeAction is recorded for every binding actioned by the consumer.
+	RecordTypeAction RecordType = "action"
+	// RecordTypeMode is recorded when the consumer is paused, resumed, armed or disarmed.
+	RecordTypeMode RecordType = "mode"
+)
+
+// Record describes an event handled by the consumer, after orientation,
+// a binding it actioned or its new mode.
+type Record struct {
+	Type   RecordType    `json:"type"`
+	Time   time.Time     `json:"time"`
+	Event  *Event        `json:"event,omitempty"`
+	Action *ActionRecord `json:"action,omitempty"`
+	Mode   Mode          `json:"mode,omitempty"`
+}
+
+// ActionRecord describes an actioned binding, what triggered it
+// and the devices it sent messages to. Pattern is the finger pattern
+// of the binding while Fingers are those observed, and Trigger is the id
+// of the device trigger of the binding, empty for bindings without one.
+type ActionRecord struct {
+	Binding  string                `json:"binding,omitempty"`
+	Gesture  config.Gesture        `json:"gesture,omitempty"`
+	Hand     string                `json:"hand,omitempty"`
+	Fingers  *config.FingerPattern `json:"fingers,omitempty"`
+	Pattern  *config.FingerPattern `json:"pattern,omitempty"`
+	Trigger  string                `json:"trigger,omitempty"`
+	Presence config.Presence       `json:"presence,omitempty"`
+	Serials  []string              `json:"serials"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// Recorder receives the records of the consumer. Record is called
+// while handling events and must not block.
+type Recorder interface {
+	Record(r Record)
+}
+
+// SetRecorder sets the recorder notified of the events and actions of the consumer.
+func (c *Consumer) SetRecorder(r Recorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = r
+}
+
+func (c *Consumer) recordEvent(hands []Hand) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Record(Record{Type: RecordTypeEvent, Time: c.lastEvent, Event: &Event{Hands: hands}})
+}
+
+func (c *Consumer) recordMode() {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Record(Record{Type: RecordTypeMode, Time: c.now(), Mode: c.mode()})
+}
+
+func (c *Consumer) recordAction(t trigger, serials []device.Serial, err error) {
+	if c.recorder == nil {
+		return
+	}
+	a := &ActionRecord{
+		Binding:  t.Binding,
+		Gesture:  t.Gesture,
+		Hand:     string(t.Hand),
+		Pattern:  t.Pattern,
+		Trigger:  t.TriggerID,
+		Presence: t.Presence,
+		Serials:  make([]string, len(serials)),
+	}
+	if t.Hand != "" {
+		a.Fingers = &t.Fingers
+	}
+	for i, s := range serials {
+		a.Serials[i] = s.String()
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	c.recorder.Record(Record{Type: RecordTypeAction, Time: t.Time, Action: a})
+}
+
+// recordingController keeps track of the devices messages are sent to.
+type recordingController struct {
+	lanController
+	serials []device.Serial
+}
+
+func (r *recordingController) Send(serial device.Serial, msg *protocol.Message) error {
+	if !slices.Contains(r.serials, serial) {
+		r.serials = append(r.serials, serial)
+	}
+	return r.lanController.Send(serial, msg)
+}
@@ -0,0 +1,125 @@
+package consumer
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRecorder struct {
+	records []Record
+}
+
+func (m *mockRecorder) Record(r Record) {
+	m.records = append(m.records, r)
+}
+
+type failingController struct {
+	mockController
+}
+
+func (f *failingController) Send(device.Serial, *protocol.Message) error {
+	return errors.New("send failed")
+}
+
+func TestConsumerRecord(t *testing.T) {
+	var (
+		now        = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		devices    = []device.Device{{Serial: serial0, Group: "Bedroom"}, {Serial: serial1}}
+		peace      = config.FingerPattern{0, 1, 1, 0, 0}
+		near       = config.FingerPattern{0, 1, 1, 1, 0}
+		bindings   = []config.Binding{
+			{Name: "up", Gesture: config.GestureSwipeUp, Action: config.ActionPowerOn, Selector: config.Selector{Type: config.SelectorTypeAll}},
+			{Pattern: &peace, Tolerance: 1, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeGroup, Value: "Bedroom"}},
+			{Fallback: true, Action: config.ActionPowerOn, Selector: config.Selector{Type: config.SelectorTypeGroup, Value: "Bedroom"}},
+		}
+	)
+
+	testCases := map[string]struct {
+		ctrl  lanController
+		event *Event
+		want  []Record
+	}{
+		"event without action": {
+			ctrl:  &mockController{devices: devices},
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: config.FingerPattern{1, 1, 1, 1, 1}}}}},
+			},
+		},
+		"gesture action": {
+			ctrl:  &mockController{devices: devices},
+			event: &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeUp}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeUp}}}},
+				{Type: RecordTypeAction, Time: now, Action: &ActionRecord{
+					Binding: "up", Gesture: config.GestureSwipeUp, Hand: "right", Fingers: &config.FingerPattern{}, Trigger: "up",
+					Serials: []string{"d073d5000000", "d073d5000001"},
+				}},
+			},
+		},
+		"pattern action": {
+			ctrl:  &mockController{devices: devices},
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace}}}},
+				{Type: RecordTypeAction, Time: now, Action: &ActionRecord{
+					Hand: "left", Fingers: &peace, Pattern: &peace, Trigger: "pattern_01100", Serials: []string{"d073d5000000"},
+				}},
+			},
+		},
+		"pattern action within tolerance": {
+			ctrl:  &mockController{devices: devices},
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: near}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: near}}}},
+				{Type: RecordTypeAction, Time: now, Action: &ActionRecord{
+					Hand: "left", Fingers: &near, Pattern: &peace, Trigger: "pattern_01100", Serials: []string{"d073d5000000"},
+				}},
+			},
+		},
+		"fallback action has no trigger": {
+			ctrl:  &mockController{devices: devices},
+			event: &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeDown}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: RightHandLabel, Gesture: config.GestureSwipeDown}}}},
+				{Type: RecordTypeAction, Time: now, Action: &ActionRecord{
+					Gesture: config.GestureSwipeDown, Hand: "right", Fingers: &config.FingerPattern{}, Serials: []string{"d073d5000000"},
+				}},
+			},
+		},
+		"failed action": {
+			ctrl:  &failingController{mockController{devices: devices}},
+			event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace}}},
+			want: []Record{
+				{Type: RecordTypeEvent, Time: now, Event: &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace}}}},
+				{Type: RecordTypeAction, Time: now, Action: &ActionRecord{
+					Hand: "left", Fingers: &peace, Pattern: &peace, Trigger: "pattern_01100", Serials: []string{"d073d5000000"}, Error: "send failed",
+				}},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Bindings: bindings}
+			c := New(cfg, tc.ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			c.now = func() time.Time { return now }
+			defer c.Close()
+			r := &mockRecorder{}
+			c.SetRecorder(r)
+
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.want, r.records)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+// pendingTimer is an action scheduled by a schedule_action binding.
+// It holds the whole action so that it can be persisted and resumed.
+type pendingTimer struct {
+	ID           int             `json:"id"`
+	FireAt       time.Time       `json:"fire_at"`
+	Action       config.Action   `json:"action"`
+	HSBK         *config.HSBK    `json:"hsbk,omitempty"`
+	Selector     config.Selector `json:"selector"`
+	TransitionMs int             `json:"transition_ms,omitempty"`
+
+	timer *time.Timer
+}
+
+// timers keeps track of the pending scheduled actions.
+type timers struct {
+	cfg    *config.Config
+	ctrl   lanController
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]*pendingTimer
+	closed  bool
+}
+
+func newTimers(cfg *config.Config, ctrl lanController, logger *slog.Logger) *timers {
+	return &timers{
+		cfg:     cfg,
+		ctrl:    ctrl,
+		logger:  logger,
+		pending: make(map[int]*pendingTimer),
+	}
+}
+
+// scheduleFunc returns a sendFunc scheduling the given action after its delay.
+func (t *timers) scheduleFunc(s *config.Schedule, selector config.Selector, transitionMs int) sendFunc {
+	return func(lanController, trigger) error {
+		return t.schedule(&pendingTimer{
+			FireAt:       time.Now().Add(time.Duration(s.DelayMs) * time.Millisecond),
+			Action:       s.Action,
+			HSBK:         s.HSBK,
+			Selector:     selector,
+			TransitionMs: transitionMs,
+		})
+	}
+}
+
+func (t *timers) schedule(pt *pendingTimer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return errors.New("timers are closed")
+	}
+	if max := t.cfg.Timers.MaxPending; max > 0 && len(t.pending) >= max {
+		return fmt.Errorf("too many pending timers, max %d", max)
+	}
+
+	t.nextID++
+	pt.ID = t.nextID
+	id := pt.ID
+	pt.timer = time.AfterFunc(time.Until(pt.FireAt), func() { t.fire(id) })
+	t.pending[id] = pt
+
+	t.logger.Info("scheduled action", slog.Int("id", id), slog.Any("action", pt.Action), slog.Time("fire_at", pt.FireAt))
+	t.changed()
+	return nil
+}
+
+// fire runs the action of the given timer, if still pending.
+func (t *timers) fire(id int) {
+	t.mu.Lock()
+	pt, ok := t.pending[id]
+	if !ok || t.closed {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.pending, id)
+	t.changed()
+	t.mu.Unlock()
+
+	t.logger.Info("firing scheduled action", slog.Int("id", id), slog.Any("action", pt.Action))
+	send := actionSendFunc(pt.Action, pt.HSBK, selectorTargets(pt.Selector, nil), transition(t.cfg, pt.TransitionMs))
+	if send == nil {
+		return
+	}
+	if err := send(t.ctrl, trigger{}); err != nil {
+		t.logger.Warn("failed to action scheduled action", slog.Int("id", id), slog.Any("error", err))
+	}
+}
+
+// cancelAll stops and removes all the pending timers.
+func (t *timers) cancelAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, pt := range t.pending {
+		pt.timer.Stop()
+		delete(t.pending, id)
+	}
+	t.logger.Info("cancelled pending timers")
+	t.changed()
+}
+
+// close stops all the pending timers on shutdown, leaving them
+// in the persist file, if any, so they are resumed on restart.
+func (t *timers) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	for _, pt := range t.pending {
+		pt.timer.Stop()
+	}
+	if len(t.pending) > 0 {
+		t.logger.Info("stopped pending timers", slog.Int("count", len(t.pending)))
+	}
+}
+
+// list returns the pending timers ordered by firing time.
+func (t *timers) list() []pendingTimer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sorted()
+}
+
+func (t *timers) sorted() []pendingTimer {
+	l := make([]pendingTimer, 0, len(t.pending))
+	for _, pt := range t.pending {
+		l = append(l, *pt)
+	}
+	slices.SortFunc(l, func(a, b pendingTimer) int { return a.FireAt.Compare(b.FireAt) })
+	return l
+}
+
+// changed logs the pending timers and persists them, if enabled.
+// It must be called with the lock held.
+func (t *timers) changed() {
+	l := t.sorted()
+	t.logger.Debug("pending timers", slog.Any("timers", l))
+
+	path := t.cfg.Timers.PersistFile
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.logger.Warn("failed to encode pending timers", slog.Any("error", err))
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.logger.Warn("failed to persist pending timers", slog.Any("error", err))
+	}
+}
+
+// resume schedules the timers saved in the persist file, if enabled.
+// Timers due while not running fire immediately.
+func (t *timers) resume() {
+	path := t.cfg.Timers.PersistFile
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			t.logger.Warn("failed to read pending timers", slog.Any("error", err))
+		}
+		return
+	}
+
+	var l []pendingTimer
+	if err := json.Unmarshal(data, &l); err != nil {
+		t.logger.Warn("failed to decode pending timers", slog.Any("error", err))
+		return
+	}
+	for _, pt := range l {
+		if err := t.schedule(&pt); err != nil {
+			t.logger.Warn("failed to resume timer", slog.Any("error", err))
+		}
+	}
+}
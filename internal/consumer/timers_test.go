@@ -0,0 +1,141 @@
+package consumer
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerTimers(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		devices    = []device.Device{{Serial: serial0}}
+		fist       = config.FingerPattern{0, 0, 0, 0, 0}
+		bindings   = func(delayMs int) []config.Binding {
+			return []config.Binding{
+				{
+					Gesture:  config.GestureSwipeDown,
+					Action:   config.ActionScheduleAction,
+					Selector: config.Selector{Type: config.SelectorTypeAll},
+					Schedule: &config.Schedule{DelayMs: delayMs, Action: config.ActionPowerOff},
+				},
+				{
+					Pattern: &fist,
+					Action:  config.ActionCancelTimers,
+				},
+			}
+		}
+		schedule = &Event{Hands: []Hand{{Gesture: config.GestureSwipeDown}}}
+		cancel   = &Event{Hands: []Hand{{Fingers: fist}}}
+	)
+
+	t.Run("fires scheduled action after delay", func(t *testing.T) {
+		cfg := &config.Config{General: config.General{TransitionMs: 1}, Bindings: bindings(20)}
+		ctrl := &mockController{devices: devices}
+		c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+
+		c.HandleEvent(schedule)
+		assert.Len(t, c.timers.list(), 1)
+		assert.Equal(t, 0, ctrl.sent())
+		assert.Eventually(t, func() bool { return ctrl.sent() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, map[device.Serial][]*protocol.Message{serial0: {setLightPower(false, time.Millisecond)}}, ctrl.messages)
+		assert.Empty(t, c.timers.list())
+	})
+
+	t.Run("cancels pending timers", func(t *testing.T) {
+		cfg := &config.Config{General: config.General{TransitionMs: 1}, Bindings: bindings(50)}
+		ctrl := &mockController{devices: devices}
+		c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+
+		c.HandleEvent(schedule)
+		c.HandleEvent(schedule)
+		assert.Len(t, c.timers.list(), 2)
+		c.HandleEvent(cancel)
+		assert.Empty(t, c.timers.list())
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 0, ctrl.sent())
+	})
+
+	t.Run("limits pending timers", func(t *testing.T) {
+		cfg := &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Timers:   config.Timers{MaxPending: 2},
+			Bindings: bindings(60000),
+		}
+		ctrl := &mockController{devices: devices}
+		c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+
+		for range 3 {
+			c.HandleEvent(schedule)
+		}
+		assert.Len(t, c.timers.list(), 2)
+	})
+
+	t.Run("held pattern schedules once", func(t *testing.T) {
+		peace := config.FingerPattern{0, 1, 1, 0, 0}
+		cfg := &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{{
+				Pattern:  &peace,
+				Action:   config.ActionScheduleAction,
+				Selector: config.Selector{Type: config.SelectorTypeAll},
+				Schedule: &config.Schedule{DelayMs: 60000, Action: config.ActionPowerOff},
+			}},
+		}
+		ctrl := &mockController{devices: devices}
+		c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+
+		held := &Event{Hands: []Hand{{Label: RightHandLabel, Fingers: peace}}}
+		for range 30 {
+			c.HandleEvent(held)
+		}
+		assert.Len(t, c.timers.list(), 1)
+
+		// Releasing the pattern schedules again.
+		c.HandleEvent(&Event{Hands: []Hand{{Label: RightHandLabel, Fingers: fist}}})
+		c.HandleEvent(held)
+		assert.Len(t, c.timers.list(), 2)
+	})
+
+	t.Run("persists and resumes timers", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "timers.json")
+		cfg := &config.Config{
+			General:  config.General{TransitionMs: 1},
+			Timers:   config.Timers{PersistFile: path},
+			Bindings: bindings(100),
+		}
+		ctrl := &mockController{devices: devices}
+		c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		c.HandleEvent(schedule)
+		c.Close()
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var saved []pendingTimer
+		require.NoError(t, json.Unmarshal(data, &saved))
+		assert.Len(t, saved, 1)
+
+		c = New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+		defer c.Close()
+		assert.Len(t, c.timers.list(), 1)
+		assert.Eventually(t, func() bool { return ctrl.sent() == 1 }, time.Second, 5*time.Millisecond)
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.JSONEq(t, "[]", string(data))
+	})
+}
@@ -0,0 +1,86 @@
+package consumer
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+// unhandledInterval is the interval over which repeated unhandled
+// gestures and patterns are summarised.
+const unhandledInterval = time.Minute
+
+// unhandledKey identifies an unhandled gesture, or pattern when gesture is empty.
+type unhandledKey struct {
+	gesture config.Gesture
+	fingers config.FingerPattern
+}
+
+// unhandledEntry counts the occurrences of an unhandled gesture or pattern
+// since its last log.
+type unhandledEntry struct {
+	msg   string
+	since time.Time
+	count int
+}
+
+// unhandledLog rate limits the warnings of gestures and patterns matching no
+// binding, which are reported on every event. The first occurrence is logged
+// immediately and the following ones are summarised once per interval.
+type unhandledLog struct {
+	logger   *slog.Logger
+	interval time.Duration
+	entries  map[unhandledKey]*unhandledEntry
+}
+
+func newUnhandledLog(logger *slog.Logger) *unhandledLog {
+	return &unhandledLog{
+		logger:   logger,
+		interval: unhandledInterval,
+		entries:  make(map[unhandledKey]*unhandledEntry),
+	}
+}
+
+// gesture records an unhandled gesture of the given hand.
+func (u *unhandledLog) gesture(now time.Time, h Hand) {
+	u.record(now, unhandledKey{gesture: h.Gesture}, "unhandled gesture",
+		slog.Any("hand", h.Label), slog.Any("gesture", h.Gesture))
+}
+
+// pattern records an unhandled finger pattern of the given hand.
+func (u *unhandledLog) pattern(now time.Time, h Hand) {
+	u.record(now, unhandledKey{fingers: h.Fingers}, "unhandled finger binding",
+		slog.Any("hand", h.Label), slog.Any("fingers", h.Fingers))
+}
+
+func (u *unhandledLog) record(now time.Time, k unhandledKey, msg string, attrs ...any) {
+	if e, ok := u.entries[k]; ok {
+		e.count++
+		return
+	}
+	u.logger.Warn(msg, attrs...)
+	u.entries[k] = &unhandledEntry{msg: msg, since: now}
+}
+
+// flush logs a summary of the entries repeated over the last interval,
+// and forgets those that did not repeat.
+func (u *unhandledLog) flush(now time.Time) {
+	for k, e := range u.entries {
+		if now.Sub(e.since) < u.interval {
+			continue
+		}
+		if e.count == 0 {
+			delete(u.entries, k)
+			continue
+		}
+		attrs := []any{slog.Int("count", e.count), slog.Duration("over", now.Sub(e.since))}
+		if k.gesture != "" {
+			attrs = append(attrs, slog.Any("gesture", k.gesture))
+		} else {
+			attrs = append(attrs, slog.Any("fingers", k.fingers))
+		}
+		u.logger.Warn(e.msg, attrs...)
+		e.since, e.count = now, 0
+	}
+}
@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnhandledLog(t *testing.T) {
+	var (
+		start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		swipe = Hand{Label: LeftHandLabel, Gesture: config.GestureSwipeUp}
+		fist  = Hand{Label: RightHandLabel, Fingers: config.FingerPattern{0, 0, 0, 0, 0}}
+		peace = Hand{Label: RightHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}}
+	)
+
+	type step struct {
+		offset  time.Duration
+		gesture *Hand
+		pattern *Hand
+	}
+	testCases := map[string]struct {
+		steps    []step
+		wantLogs []string
+	}{
+		"first occurrence is logged immediately": {
+			steps: []step{{0, &swipe, nil}},
+			wantLogs: []string{
+				`level=WARN msg="unhandled gesture" hand=left gesture=swipe_up`,
+			},
+		},
+		"repeats are summarised once per interval": {
+			steps: []step{{0, nil, &fist}, {time.Second, nil, &fist}, {2 * time.Second, nil, &fist}, {time.Minute, nil, nil}, {90 * time.Second, nil, nil}},
+			wantLogs: []string{
+				`level=WARN msg="unhandled finger binding" hand=right fingers=00000`,
+				`level=WARN msg="unhandled finger binding" count=2 over=1m0s fingers=00000`,
+			},
+		},
+		"patterns are summarised separately": {
+			steps: []step{{0, nil, &fist}, {time.Second, nil, &peace}, {2 * time.Second, nil, &peace}, {time.Minute, nil, nil}},
+			wantLogs: []string{
+				`level=WARN msg="unhandled finger binding" hand=right fingers=00000`,
+				`level=WARN msg="unhandled finger binding" hand=right fingers=01100`,
+			},
+		},
+		"logged again once no longer repeated": {
+			steps: []step{{0, &swipe, nil}, {time.Minute, nil, nil}, {2 * time.Minute, &swipe, nil}},
+			wantLogs: []string{
+				`level=WARN msg="unhandled gesture" hand=left gesture=swipe_up`,
+				`level=WARN msg="unhandled gesture" hand=left gesture=swipe_up`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			u := newUnhandledLog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})))
+			for _, s := range tc.steps {
+				now := start.Add(s.offset)
+				u.flush(now)
+				if s.gesture != nil {
+					u.gesture(now, *s.gesture)
+				}
+				if s.pattern != nil {
+					u.pattern(now, *s.pattern)
+				}
+			}
+			assert.Equal(t, tc.wantLogs, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+		})
+	}
+}
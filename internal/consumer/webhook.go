@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+)
+
+const (
+	// maxConcurrentWebhooks caps the number of webhook requests in flight.
+	maxConcurrentWebhooks = 8
+
+	defaultWebhookTimeout = 5 * time.Second
+	defaultWebhookBackoff = 500 * time.Millisecond
+)
+
+// webhookPayload is the JSON body posted by webhook bindings.
+type webhookPayload struct {
+	Binding   string                `json:"binding,omitempty"`
+	Gesture   config.Gesture        `json:"gesture,omitempty"`
+	Hand      label                 `json:"hand,omitempty"`
+	Fingers   *config.FingerPattern `json:"fingers,omitempty"`
+	Presence  config.Presence       `json:"presence,omitempty"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+// webhookFunc returns a sendFunc posting the trigger to the webhook in the background.
+func (e *executor) webhookFunc(w *config.Webhook) sendFunc {
+	client := &http.Client{Timeout: defaultWebhookTimeout}
+	if w.TimeoutMs > 0 {
+		client.Timeout = time.Duration(w.TimeoutMs) * time.Millisecond
+	}
+
+	return func(_ lanController, t trigger) error {
+		p := webhookPayload{
+			Binding:   t.Binding,
+			Gesture:   t.Gesture,
+			Hand:      t.Hand,
+			Presence:  t.Presence,
+			Timestamp: t.Time,
+		}
+		// Fingers are only meaningful when the trigger has a hand.
+		if t.Hand != "" || t.Fingers != (config.FingerPattern{}) {
+			p.Fingers = &t.Fingers
+		}
+		body, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return e.start(func(ctx context.Context) { e.post(ctx, client, w, body) })
+	}
+}
+
+// post sends the body to the webhook, retrying with exponential backoff on failure.
+func (e *executor) post(ctx context.Context, client *http.Client, w *config.Webhook, body []byte) {
+	backoff := defaultWebhookBackoff
+	if w.BackoffMs > 0 {
+		backoff = time.Duration(w.BackoffMs) * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := e.postOnce(ctx, client, w, body)
+		if err == nil {
+			e.logger.Debug("webhook sent", slog.String("url", w.URL))
+			return
+		}
+		if attempt >= w.Retries {
+			e.logger.Warn("webhook failed", slog.String("url", w.URL), slog.Int("attempts", attempt+1), slog.Any("error", err))
+			return
+		}
+		e.logger.Debug("retrying webhook", slog.String("url", w.URL), slog.Duration("backoff", backoff), slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (e *executor) postOnce(ctx context.Context, client *http.Client, w *config.Webhook, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
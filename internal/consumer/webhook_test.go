@@ -0,0 +1,136 @@
+package consumer
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerWebhook(t *testing.T) {
+	var (
+		now      = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		peace    = config.FingerPattern{0, 1, 1, 0, 0}
+		newEvent = func() *Event {
+			return &Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace, Gesture: config.GestureSwipeUp}}}
+		}
+	)
+
+	type request struct {
+		header  http.Header
+		payload map[string]any
+	}
+	newServer := func(failures int) (*httptest.Server, func() []request) {
+		var (
+			mu       sync.Mutex
+			requests []request
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var p map[string]any
+			json.NewDecoder(r.Body).Decode(&p)
+			mu.Lock()
+			requests = append(requests, request{header: r.Header, payload: p})
+			n := len(requests)
+			mu.Unlock()
+			if n <= failures {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		return srv, func() []request {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]request(nil), requests...)
+		}
+	}
+	newConsumer := func(w *config.Webhook) *Consumer {
+		cfg := &config.Config{
+			General: config.General{TransitionMs: 1},
+			Bindings: []config.Binding{
+				{Name: "music", Gesture: config.GestureSwipeUp, Action: config.ActionWebhook, Webhook: w},
+				{Name: "movie_mode", Pattern: &peace, Action: config.ActionWebhook, Webhook: w},
+			},
+		}
+		c := New(cfg, &mockController{}, logger.NewLogger(slog.LevelInfo, ""))
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("posts trigger payload with headers", func(t *testing.T) {
+		srv, requests := newServer(0)
+		defer srv.Close()
+		c := newConsumer(&config.Webhook{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+		defer c.Close()
+
+		c.HandleEvent(newEvent())
+		c.webhooks.wg.Wait()
+
+		got := requests()
+		assert.Len(t, got, 1)
+		assert.Equal(t, "Bearer token", got[0].header.Get("Authorization"))
+		assert.Equal(t, "application/json", got[0].header.Get("Content-Type"))
+		assert.Equal(t, map[string]any{
+			"binding":   "music",
+			"gesture":   "swipe_up",
+			"hand":      "left",
+			"fingers":   []any{0.0, 1.0, 1.0, 0.0, 0.0},
+			"timestamp": "2025-01-01T12:00:00Z",
+		}, got[0].payload)
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		srv, requests := newServer(2)
+		defer srv.Close()
+		c := newConsumer(&config.Webhook{URL: srv.URL, Retries: 3, BackoffMs: 10})
+		defer c.Close()
+
+		c.HandleEvent(newEvent())
+		c.webhooks.wg.Wait()
+		assert.Len(t, requests(), 3)
+	})
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		srv, requests := newServer(10)
+		defer srv.Close()
+		c := newConsumer(&config.Webhook{URL: srv.URL, Retries: 1, BackoffMs: 10})
+		defer c.Close()
+
+		c.HandleEvent(newEvent())
+		c.webhooks.wg.Wait()
+		assert.Len(t, requests(), 2)
+	})
+
+	t.Run("held pattern posts once", func(t *testing.T) {
+		srv, requests := newServer(0)
+		defer srv.Close()
+		c := newConsumer(&config.Webhook{URL: srv.URL})
+		defer c.Close()
+
+		for range 10 {
+			c.HandleEvent(&Event{Hands: []Hand{{Label: LeftHandLabel, Fingers: peace}}})
+		}
+		c.webhooks.wg.Wait()
+		got := requests()
+		assert.Len(t, got, 1)
+		assert.Equal(t, "movie_mode", got[0].payload["binding"])
+	})
+
+	t.Run("does not block on slow server", func(t *testing.T) {
+		block := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-block }))
+		defer srv.Close()
+		defer close(block)
+		c := newConsumer(&config.Webhook{URL: srv.URL, TimeoutMs: 5000})
+		defer c.Close()
+
+		start := time.Now()
+		c.HandleEvent(newEvent())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,60 @@
+package consumer
+
+import (
+	"fmt"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+)
+
+// targetFunc returns the devices targeted by a binding for the given trigger.
+type targetFunc func(ctrl lanController, t trigger) ([]device.Serial, error)
+
+// selectorTargets returns a targetFunc resolving the devices matching the
+// selector when called, or nil if the selector is invalid. Zone selectors
+// resolve the devices of the given zone containing the hand of the trigger.
+func selectorTargets(selector config.Selector, zones []config.Zone) targetFunc {
+	switch selector.Type {
+	case config.SelectorTypeSerial:
+		serials := []device.Serial{selector.Serial}
+		return func(lanController, trigger) ([]device.Serial, error) { return serials, nil }
+	case config.SelectorTypeZone:
+		if len(zones) == 0 {
+			return nil
+		}
+		return zoneTargets(zones)
+	}
+	cond := selectorCondition(selector)
+	if cond == nil {
+		return nil
+	}
+	return func(ctrl lanController, _ trigger) ([]device.Serial, error) {
+		return targetForCondition(ctrl.GetDevices(), cond), nil
+	}
+}
+
+// zoneTargets returns a targetFunc resolving the devices of the zone containing
+// the palm and wrist of the hand of the trigger. Bindings are skipped when
+// the position of the hand is not reported or is outside all the zones.
+func zoneTargets(zones []config.Zone) targetFunc {
+	targets := make([]targetFunc, len(zones))
+	for i, z := range zones {
+		targets[i] = selectorTargets(z.Selector, nil)
+	}
+	return func(ctrl lanController, t trigger) ([]device.Serial, error) {
+		if t.source == nil {
+			return nil, fmt.Errorf("%w: no hand position", errSkipped)
+		}
+		x, okX := t.source.Position(config.DialSourcePalmX)
+		y, okY := t.source.Position(config.DialSourceWristY)
+		if !okX || !okY {
+			return nil, fmt.Errorf("%w: no hand position", errSkipped)
+		}
+		for i := range zones {
+			if zones[i].Contains(x, y) && targets[i] != nil {
+				return targets[i](ctrl, t)
+			}
+		}
+		return nil, fmt.Errorf("%w: hand outside zones", errSkipped)
+	}
+}
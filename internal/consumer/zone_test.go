@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerZones(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		serial1, _ = device.SerialFromHex("d073d5000001")
+		serial2, _ = device.SerialFromHex("d073d5000002")
+		devices    = []device.Device{{Serial: serial0, Label: "Lamp"}, {Serial: serial1, Group: "Ceiling"}, {Serial: serial2, Group: "Ceiling"}}
+		zones      = []config.Zone{
+			{Name: "lamp", XMin: 0, XMax: 0.33, Selector: config.Selector{Type: config.SelectorTypeLabel, Value: "Lamp"}},
+			{Name: "ceiling", XMin: 0.66, XMax: 1, YMin: 0, YMax: 0.5, Selector: config.Selector{Type: config.SelectorTypeGroup, Value: "Ceiling"}},
+		}
+		open     = config.FingerPattern{1, 1, 1, 1, 1}
+		bindings = []config.Binding{
+			{Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeZone}},
+			{Pattern: &open, Action: config.ActionPowerOn, Selector: config.Selector{Type: config.SelectorTypeZone}},
+		}
+		on  = setLightPower(true, time.Millisecond)
+		off = setLightPower(false, time.Millisecond)
+		at  = func(x, y float64) Hand {
+			return Hand{Label: RightHandLabel, PalmX: &x, WristY: &y}
+		}
+		swipe = func(h Hand) *Event {
+			h.Gesture = config.GestureSwipeDown
+			return &Event{Hands: []Hand{h}}
+		}
+		show = func(h Hand) *Event {
+			h.Fingers = open
+			return &Event{Hands: []Hand{h}}
+		}
+	)
+
+	testCases := map[string]struct {
+		tracking     config.Tracking
+		event        *Event
+		wantMessages map[device.Serial][]*protocol.Message
+	}{
+		"gesture in first zone": {
+			event:        swipe(at(0.1, 0.9)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial0: {off}},
+		},
+		"pattern in second zone": {
+			event:        show(at(0.8, 0.2)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial1: {on}, serial2: {on}},
+		},
+		"zone edge": {
+			event:        show(at(0.66, 0.5)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial1: {on}, serial2: {on}},
+		},
+		"outside zone height": {
+			event: show(at(0.8, 0.7)),
+		},
+		"between zones": {
+			event: swipe(at(0.5, 0.5)),
+		},
+		"mirrored gesture in first zone": {
+			tracking:     config.Tracking{MirrorHorizontal: true},
+			event:        swipe(at(0.9, 0.9)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial0: {off}},
+		},
+		"flipped pattern in second zone": {
+			tracking:     config.Tracking{FlipVertical: true},
+			event:        show(at(0.8, 0.8)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial1: {on}, serial2: {on}},
+		},
+		"mirrored and flipped pattern in second zone": {
+			tracking:     config.Tracking{MirrorHorizontal: true, FlipVertical: true},
+			event:        show(at(0.2, 0.8)),
+			wantMessages: map[device.Serial][]*protocol.Message{serial1: {on}, serial2: {on}},
+		},
+		"no position": {
+			event: swipe(Hand{Label: RightHandLabel}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{General: config.General{TransitionMs: 1}, Tracking: tc.tracking, Zones: zones, Bindings: bindings}
+			ctrl := &mockController{devices: devices}
+			c := New(cfg, ctrl, logger.NewLogger(slog.LevelInfo, ""))
+			defer c.Close()
+			r := &mockRecorder{}
+			c.SetRecorder(r)
+
+			c.HandleEvent(tc.event)
+			assert.Equal(t, tc.wantMessages, ctrl.messages)
+			// Skipped bindings are not recorded as actioned.
+			var actions int
+			for _, rec := range r.records {
+				if rec.Type == RecordTypeAction {
+					actions++
+				}
+			}
+			assert.Equal(t, min(len(tc.wantMessages), 1), actions)
+		})
+	}
+}
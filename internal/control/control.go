@@ -0,0 +1,202 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/alessio-palumbo/lifx-force/internal/api"
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+)
+
+// Commands accepted by the control socket.
+const (
+	CommandDevices  = "devices"
+	CommandBindings = "bindings"
+	CommandTrigger  = "trigger"
+	CommandPause    = "pause"
+	CommandResume   = "resume"
+	CommandReload   = "reload"
+	CommandLogLevel = "log_level"
+)
+
+// Request is a command sent to the control socket as a single line of JSON.
+type Request struct {
+	Command string `json:"command"`
+	Binding string `json:"binding,omitempty"`
+	Level   string `json:"level,omitempty"`
+}
+
+// Response is the reply to a Request, sent as a single line of JSON.
+type Response struct {
+	OK     bool            `json:"ok"`
+	Error  string          `json:"error,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type deviceLister interface {
+	GetDevices() []device.Device
+}
+
+// Consumer is the part of the consumer controlled through the socket.
+type Consumer interface {
+	Bindings() []config.Binding
+	Trigger(name string) error
+	Pause()
+	Resume()
+}
+
+// Server handles the commands received on a Unix socket.
+type Server struct {
+	path     string
+	ctrl     deviceLister
+	consumer Consumer
+	// reload reloads the config file and setLevel changes the log level.
+	reload   func() error
+	setLevel func(level string) error
+	logger   *slog.Logger
+}
+
+func New(path string, ctrl deviceLister, c Consumer, reload func() error, setLevel func(string) error, logger *slog.Logger) *Server {
+	return &Server{path: path, ctrl: ctrl, consumer: c, reload: reload, setLevel: setLevel, logger: logger}
+}
+
+// Run listens on the socket until the context is cancelled.
+// A socket left behind by a previous run is replaced, while an error
+// is returned if another instance is listening on it.
+func (s *Server) Run(ctx context.Context) error {
+	if _, err := os.Stat(s.path); err == nil {
+		if conn, err := net.Dial("unix", s.path); err == nil {
+			conn.Close()
+			return fmt.Errorf("control socket %s is already in use", s.path)
+		}
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+	}
+
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if err := os.Chmod(s.path, 0600); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	s.logger.Info("Listening on control socket", slog.String("path", s.path))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+// serve handles the requests of a connection, one per line, until it is closed.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var (
+			req  Request
+			resp Response
+		)
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = Response{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			resp = s.handle(req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req Request) Response {
+	var (
+		result any
+		err    error
+	)
+	switch req.Command {
+	case CommandDevices:
+		result = api.NewDevices(s.ctrl.GetDevices())
+	case CommandBindings:
+		result = api.NewBindings(s.consumer.Bindings())
+	case CommandTrigger:
+		err = s.consumer.Trigger(req.Binding)
+	case CommandPause:
+		s.consumer.Pause()
+	case CommandResume:
+		s.consumer.Resume()
+	case CommandReload:
+		err = s.reload()
+	case CommandLogLevel:
+		err = s.setLevel(req.Level)
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	s.logger.Debug("handled control command", slog.String("command", req.Command))
+	resp := Response{OK: true}
+	if result != nil {
+		if resp.Result, err = json.Marshal(result); err != nil {
+			return Response{Error: err.Error()}
+		}
+	}
+	return resp
+}
+
+// Send sends a request to the control socket at the given path and returns its response.
+func Send(path string, req Request) (*Response, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s, is lifx-force running with control enabled? %w", path, err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("connection closed without a response")
+	}
+	var resp Response
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
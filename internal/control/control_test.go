@@ -0,0 +1,237 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifxlan-go/pkg/device"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockController struct {
+	devices []device.Device
+}
+
+func (m *mockController) GetDevices() []device.Device {
+	return m.devices
+}
+
+type mockConsumer struct {
+	mu        sync.Mutex
+	bindings  []config.Binding
+	triggered []string
+	paused    bool
+}
+
+func (m *mockConsumer) Bindings() []config.Binding {
+	return m.bindings
+}
+
+func (m *mockConsumer) Trigger(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name != "off" {
+		return consumer.ErrBindingNotFound
+	}
+	m.triggered = append(m.triggered, name)
+	return nil
+}
+
+func (m *mockConsumer) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = true
+}
+
+func (m *mockConsumer) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = false
+}
+
+// socketPath returns a short socket path, as their length is limited.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "lifx-force")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "control.sock")
+}
+
+func runServer(t *testing.T, s *Server) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", s.path)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	return func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestServer(t *testing.T) {
+	var (
+		serial0, _ = device.SerialFromHex("d073d5000000")
+		bindings   = []config.Binding{
+			{Name: "off", Gesture: config.GestureSwipeDown, Action: config.ActionPowerOff, Selector: config.Selector{Type: config.SelectorTypeAll}},
+		}
+		ctrl = &mockController{devices: []device.Device{{
+			Serial:     serial0,
+			Label:      "Lamp",
+			Color:      device.Color{Brightness: 50, Kelvin: 2700},
+			LastSeenAt: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		}}}
+	)
+
+	testCases := map[string]struct {
+		req        Request
+		reloadErr  error
+		want       Response
+		wantPaused bool
+		triggered  []string
+		level      string
+	}{
+		"devices": {
+			req: Request{Command: CommandDevices},
+			want: Response{OK: true, Result: []byte(`[{"serial":"d073d5000000","label":"Lamp","product":"","type":"light","group":"","location":"",` +
+				`"powered_on":false,"color":{"hue":0,"saturation":0,"brightness":50,"kelvin":2700},"last_seen_at":"2025-01-01T12:00:00Z"}]`)},
+		},
+		"bindings": {
+			req:  Request{Command: CommandBindings},
+			want: Response{OK: true, Result: []byte(`[{"name":"off","gesture":"swipe_down","action":"power_off","selector":{"type":"all"}}]`)},
+		},
+		"trigger": {
+			req:       Request{Command: CommandTrigger, Binding: "off"},
+			want:      Response{OK: true},
+			triggered: []string{"off"},
+		},
+		"trigger unknown binding": {
+			req:  Request{Command: CommandTrigger, Binding: "on"},
+			want: Response{Error: "binding not found"},
+		},
+		"pause": {
+			req:        Request{Command: CommandPause},
+			want:       Response{OK: true},
+			wantPaused: true,
+		},
+		"resume": {
+			req:  Request{Command: CommandResume},
+			want: Response{OK: true},
+		},
+		"reload": {
+			req:  Request{Command: CommandReload},
+			want: Response{OK: true},
+		},
+		"reload failure": {
+			req:       Request{Command: CommandReload},
+			reloadErr: errors.New("invalid config"),
+			want:      Response{Error: "invalid config"},
+		},
+		"log level": {
+			req:   Request{Command: CommandLogLevel, Level: "debug"},
+			want:  Response{OK: true},
+			level: "debug",
+		},
+		"unknown command": {
+			req:  Request{Command: "restart"},
+			want: Response{Error: `unknown command "restart"`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var (
+				c     = &mockConsumer{bindings: bindings}
+				level string
+			)
+			reload := func() error { return tc.reloadErr }
+			setLevel := func(l string) error {
+				level = l
+				return nil
+			}
+			s := New(socketPath(t), ctrl, c, reload, setLevel, logger.NewLogger(slog.LevelInfo, ""))
+			stop := runServer(t, s)
+			defer stop()
+
+			resp, err := Send(s.path, tc.req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want.OK, resp.OK)
+			assert.Equal(t, tc.want.Error, resp.Error)
+			if tc.want.Result != nil {
+				assert.JSONEq(t, string(tc.want.Result), string(resp.Result))
+			} else {
+				assert.Empty(t, resp.Result)
+			}
+			assert.Equal(t, tc.wantPaused, c.paused)
+			assert.Equal(t, tc.triggered, c.triggered)
+			assert.Equal(t, tc.level, level)
+		})
+	}
+}
+
+func TestServerInvalidRequest(t *testing.T) {
+	s := New(socketPath(t), &mockController{}, &mockConsumer{}, nil, nil, logger.NewLogger(slog.LevelInfo, ""))
+	stop := runServer(t, s)
+	defer stop()
+
+	conn, err := net.Dial("unix", s.path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("not json\n"))
+	require.NoError(t, err)
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), `"ok":false,"error":"invalid request`)
+}
+
+func TestServerSocket(t *testing.T) {
+	log := logger.NewLogger(slog.LevelInfo, "")
+
+	t.Run("replaces stale socket", func(t *testing.T) {
+		path := socketPath(t)
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+
+		s := New(path, &mockController{}, &mockConsumer{}, nil, nil, log)
+		stop := runServer(t, s)
+		defer stop()
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.ModeSocket, info.Mode().Type())
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("socket in use", func(t *testing.T) {
+		path := socketPath(t)
+		stop := runServer(t, New(path, &mockController{}, &mockConsumer{}, nil, nil, log))
+		defer stop()
+
+		err := New(path, &mockController{}, &mockConsumer{}, nil, nil, log).Run(context.Background())
+		assert.ErrorContains(t, err, "already in use")
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		_, err := Send(socketPath(t), Request{Command: CommandPause})
+		assert.ErrorContains(t, err, "is lifx-force running")
+	})
+}
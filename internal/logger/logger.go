@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
@@ -8,24 +9,48 @@ import (
 	"github.com/alessio-palumbo/lifx-force/internal/config"
 )
 
+// Level is the level of the logger returned by SetupLogger,
+// which can be changed while running.
+var Level = new(slog.LevelVar)
+
 // SetupLogger sets the logging level and output.
 // If no logging file is supplied in the config then stdout is used.
 func SetupLogger(cfg *config.Config) *slog.Logger {
-	level := slog.LevelInfo
+	level, err := ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	Level.Set(level)
+
+	return NewLogger(Level, cfg.Logging.File)
+}
 
-	switch cfg.Logging.Level {
+// ParseLevel converts a config logging level into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	switch s {
 	case "debug":
-		level = slog.LevelDebug
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
 	case "warn":
-		level = slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "error":
-		level = slog.LevelError
+		return slog.LevelError, nil
 	}
+	return slog.LevelInfo, fmt.Errorf("level must be one of debug, info, warn, error")
+}
 
-	return NewLogger(level, cfg.Logging.File)
+// SetLevel changes the level of the logger returned by SetupLogger.
+func SetLevel(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	Level.Set(level)
+	return nil
 }
 
-func NewLogger(level slog.Level, logFile string) *slog.Logger {
+func NewLogger(level slog.Leveler, logFile string) *slog.Logger {
 	var handler slog.Handler
 	if logFile != "" {
 		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -0,0 +1,142 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/version"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// haDevice groups the discovered entities under a single Home Assistant device.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version"`
+}
+
+// haTrigger is the discovery payload of a device trigger.
+type haTrigger struct {
+	AutomationType string   `json:"automation_type"`
+	Topic          string   `json:"topic"`
+	Type           string   `json:"type"`
+	Subtype        string   `json:"subtype"`
+	Device         haDevice `json:"device"`
+}
+
+// haSensor is the discovery payload of a sensor.
+type haSensor struct {
+	Name       string   `json:"name"`
+	UniqueID   string   `json:"unique_id"`
+	StateTopic string   `json:"state_topic"`
+	Icon       string   `json:"icon,omitempty"`
+	Device     haDevice `json:"device"`
+}
+
+// SetBindings replaces the bindings advertised to Home Assistant,
+// removing the device triggers of the bindings no longer configured.
+func (m *Client) SetBindings(bindings []config.Binding) {
+	m.mu.Lock()
+	m.bindings = bindings
+	m.mu.Unlock()
+	if m.client.IsConnected() {
+		m.publishDiscovery()
+	}
+}
+
+// publishDiscovery publishes the retained discovery payloads of lifx-force
+// status sensor and of a device trigger for each gesture, pattern and named count binding.
+func (m *Client) publishDiscovery() {
+	if !m.cfg.Discovery.Enabled {
+		return
+	}
+
+	node := m.nodeID()
+	device := haDevice{
+		Identifiers:  []string{node},
+		Name:         "lifx-force",
+		Manufacturer: "lifx-force",
+		Model:        "lifx-force",
+		SWVersion:    version.Version,
+	}
+	payloads := map[string]any{
+		m.discoveryTopic("sensor", "status"): haSensor{
+			Name:       "Status",
+			UniqueID:   node + "_status",
+			StateTopic: m.topic("status"),
+			Icon:       "mdi:gesture",
+			Device:     device,
+		},
+	}
+
+	m.mu.Lock()
+	for _, b := range m.bindings {
+		id := b.TriggerID()
+		if id == "" {
+			continue
+		}
+		typ, subtype := "gesture", string(b.Gesture)
+		switch {
+		case b.Pattern != nil:
+			typ, subtype = "pattern", b.Pattern.String()
+		case b.Count != nil:
+			typ = "count"
+		}
+		if b.Name != "" {
+			subtype = b.Name
+		}
+		payloads[m.discoveryTopic("device_automation", id)] = haTrigger{
+			AutomationType: "trigger",
+			Topic:          m.topic("trigger/" + id),
+			Type:           typ,
+			Subtype:        subtype,
+			Device:         device,
+		}
+	}
+	stale := m.discovered
+	m.discovered = make(map[string]bool, len(payloads))
+	for topic := range payloads {
+		m.discovered[topic] = true
+		delete(stale, topic)
+	}
+	m.mu.Unlock()
+
+	for topic, p := range payloads {
+		payload, err := json.Marshal(p)
+		if err != nil {
+			m.logger.Warn("failed to encode discovery payload", slog.String("topic", topic), slog.Any("error", err))
+			continue
+		}
+		m.publishTopic(topic, string(payload), true)
+	}
+	for topic := range stale {
+		m.publishTopic(topic, "", true)
+	}
+	m.logger.Debug("published Home Assistant discovery", slog.Int("entities", len(payloads)), slog.Int("removed", len(stale)))
+}
+
+// handleDiscovered removes the retained device triggers, published by a
+// previous run, of the bindings that are no longer configured.
+func (m *Client) handleDiscovered(_ paho.Client, msg paho.Message) {
+	if len(msg.Payload()) == 0 {
+		return
+	}
+	m.mu.Lock()
+	current := m.discovered[msg.Topic()]
+	m.mu.Unlock()
+	if !current {
+		m.logger.Debug("removing stale Home Assistant trigger", slog.String("topic", msg.Topic()))
+		m.publishTopic(msg.Topic(), "", true)
+	}
+}
+
+func (m *Client) discoveryTopic(component, object string) string {
+	return m.cfg.Discovery.Prefix + "/" + component + "/" + m.nodeID() + "/" + object + "/config"
+}
+
+func (m *Client) nodeID() string {
+	return config.SanitizeID(m.cfg.ClientID)
+}
@@ -0,0 +1,96 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDiscovery(t *testing.T) {
+	var (
+		b      = newBroker(t, "")
+		peace  = config.FingerPattern{0, 1, 1, 0, 0}
+		near   = config.FingerPattern{0, 1, 1, 1, 0}
+		three  = 3
+		device = map[string]any{
+			"identifiers":  []any{"lifx-force-test"},
+			"name":         "lifx-force",
+			"manufacturer": "lifx-force",
+			"model":        "lifx-force",
+			"sw_version":   "dev",
+		}
+		stale = "homeassistant/device_automation/lifx-force-test/removed/config"
+	)
+	defer b.server.Close()
+
+	// A trigger retained by a previous run for a binding no longer configured.
+	require.NoError(t, b.server.Publish(stale, []byte(`{"automation_type":"trigger"}`), true, 0))
+
+	cfg := testConfig(b.addr)
+	cfg.MQTT.Discovery.Enabled = true
+	cfg.Bindings = []config.Binding{
+		{Name: "movie mode", Gesture: config.GestureExpand, Action: config.ActionWebhook},
+		{Pattern: &peace, Action: config.ActionPowerOff},
+		{Presence: config.PresenceLeave, Action: config.ActionPowerOff},
+		{Name: "scene 3", Count: &three, Action: config.ActionPowerOn},
+	}
+	client, hub, stop := newTestClient(t, cfg, &mockConsumer{})
+	defer stop()
+
+	wantPayloads := map[string]map[string]any{
+		"homeassistant/sensor/lifx-force-test/status/config": {
+			"name":        "Status",
+			"unique_id":   "lifx-force-test_status",
+			"state_topic": "lifx-force/status",
+			"icon":        "mdi:gesture",
+			"device":      device,
+		},
+		"homeassistant/device_automation/lifx-force-test/movie_mode/config": {
+			"automation_type": "trigger",
+			"topic":           "lifx-force/trigger/movie_mode",
+			"type":            "gesture",
+			"subtype":         "movie mode",
+			"device":          device,
+		},
+		"homeassistant/device_automation/lifx-force-test/pattern_01100/config": {
+			"automation_type": "trigger",
+			"topic":           "lifx-force/trigger/pattern_01100",
+			"type":            "pattern",
+			"subtype":         "01100",
+			"device":          device,
+		},
+		"homeassistant/device_automation/lifx-force-test/scene_3/config": {
+			"automation_type": "trigger",
+			"topic":           "lifx-force/trigger/scene_3",
+			"type":            "count",
+			"subtype":         "scene 3",
+			"device":          device,
+		},
+	}
+	for topic, want := range wantPayloads {
+		assert.Eventually(t, func() bool {
+			for _, m := range b.received() {
+				var got map[string]any
+				if m.topic == topic && json.Unmarshal([]byte(m.payload), &got) == nil {
+					return assert.ObjectsAreEqual(want, got)
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond, "discovery payload not received for %s", topic)
+	}
+	b.waitFor(message{stale, ""})
+
+	// Actioned bindings are published to the trigger topic of the binding,
+	// even when the observed fingers differ within tolerance.
+	hub.Record(consumer.Record{Type: consumer.RecordTypeAction, Action: &consumer.ActionRecord{Hand: "left", Fingers: &near, Pattern: &peace, Trigger: "pattern_01100", Serials: []string{}}})
+	b.waitFor(message{"lifx-force/trigger/pattern_01100", `{"hand":"left","fingers":[0,1,1,1,0],"pattern":[0,1,1,0,0],"trigger":"pattern_01100","serials":[]}`})
+
+	// Triggers of removed bindings are deleted when the bindings change.
+	client.SetBindings(cfg.Bindings[:1])
+	b.waitFor(message{"homeassistant/device_automation/lifx-force-test/pattern_01100/config", ""})
+}
@@ -0,0 +1,290 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	connectTimeout = 10 * time.Second
+	publishTimeout = 5 * time.Second
+	// disconnectQuiesce is the time given to pending work when disconnecting, in ms.
+	disconnectQuiesce = 250
+
+	statusOnline  = "online"
+	statusOffline = "offline"
+)
+
+// Consumer is the part of the consumer controlled through MQTT commands.
+type Consumer interface {
+	Status() consumer.Status
+	Trigger(name string) error
+	SetMode(m consumer.Mode) error
+}
+
+// Client publishes the gestures, patterns and actioned bindings of the
+// consumer to an MQTT broker and handles the commands it receives.
+//
+// Topics are relative to the configured prefix:
+//   - status: online or offline, retained
+//   - mode: active, paused, armed or disarmed, retained
+//   - gesture/<hand>: the gestures detected for each hand
+//   - pattern/<hand>: the finger patterns detected for each hand, when they change
+//   - binding/<name>: the actioned bindings, as JSON, or binding for unnamed bindings
+//   - trigger/<id>: the actioned bindings, by Home Assistant device trigger id
+//   - command: JSON commands, e.g. {"command":"trigger","binding":"movie_mode"}
+type Client struct {
+	cfg      *config.MQTT
+	consumer Consumer
+	hub      *stream.Hub
+	logger   *slog.Logger
+	client   paho.Client
+	// lost receives the connection lost errors.
+	lost chan error
+	// patterns keeps the latest pattern published for each hand.
+	patterns map[string]config.FingerPattern
+
+	mu sync.Mutex
+	// bindings are advertised to Home Assistant when discovery is enabled,
+	// and discovered keeps the discovery topics currently published.
+	bindings   []config.Binding
+	discovered map[string]bool
+}
+
+func New(cfg *config.Config, c Consumer, hub *stream.Hub, logger *slog.Logger) (*Client, error) {
+	m := &Client{
+		cfg:      &cfg.MQTT,
+		consumer: c,
+		hub:      hub,
+		logger:   logger,
+		lost:     make(chan error, 1),
+		patterns: make(map[string]config.FingerPattern),
+		bindings: cfg.Bindings,
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(m.cfg.Broker).
+		SetClientID(m.cfg.ClientID).
+		SetUsername(m.cfg.Username).
+		SetPassword(m.cfg.Password).
+		SetConnectTimeout(connectTimeout).
+		SetAutoReconnect(false).
+		SetOrderMatters(false).
+		SetWill(m.topic("status"), statusOffline, byte(m.cfg.QoS), true).
+		SetOnConnectHandler(m.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			select {
+			case m.lost <- err:
+			default:
+			}
+		})
+	if m.cfg.TLS != nil {
+		tlsCfg, err := tlsConfig(m.cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsCfg)
+	}
+	m.client = paho.NewClient(opts)
+	return m, nil
+}
+
+// Run keeps the client connected until the context is cancelled,
+// reconnecting with an exponential backoff when the connection fails.
+func (m *Client) Run(ctx context.Context) {
+	sub := m.hub.Subscribe(stream.Filter{})
+	defer m.hub.Unsubscribe(sub)
+	go m.publishRecords(ctx, sub)
+
+	minBackoff := time.Duration(m.cfg.MinBackoffMs) * time.Millisecond
+	maxBackoff := time.Duration(m.cfg.MaxBackoffMs) * time.Millisecond
+	backoff := minBackoff
+	for {
+		err := m.connect()
+		if err == nil {
+			backoff = minBackoff
+			select {
+			case <-ctx.Done():
+				m.publish("status", statusOffline, true)
+				m.client.Disconnect(disconnectQuiesce)
+				return
+			case err = <-m.lost:
+			}
+		}
+
+		m.logger.Warn("MQTT connection failed, retrying", slog.Any("error", err), slog.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (m *Client) connect() error {
+	token := m.client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return errors.New("connect timeout")
+	}
+	return token.Error()
+}
+
+// onConnect announces the client and subscribes to the command topic,
+// as subscriptions do not survive reconnections with a clean session.
+// Discovery payloads are published again in case the broker restarted.
+func (m *Client) onConnect(c paho.Client) {
+	m.logger.Info("Connected to MQTT broker", slog.String("broker", m.cfg.Broker))
+	m.publish("status", statusOnline, true)
+	m.publish("mode", string(m.consumer.Status().Mode), true)
+	c.Subscribe(m.topic("command"), byte(m.cfg.QoS), m.handleCommand)
+	if m.cfg.Discovery.Enabled {
+		m.publishDiscovery()
+		c.Subscribe(m.discoveryTopic("device_automation", "+"), byte(m.cfg.QoS), m.handleDiscovered)
+	}
+}
+
+// publishRecords publishes the records of the hub until the context is cancelled.
+func (m *Client) publishRecords(ctx context.Context, sub *stream.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			m.publishRecord(r)
+		}
+	}
+}
+
+func (m *Client) publishRecord(r consumer.Record) {
+	switch r.Type {
+	case consumer.RecordTypeEvent:
+		seen := make(map[string]bool, len(r.Event.Hands))
+		for _, h := range r.Event.Hands {
+			hand := string(h.Label)
+			seen[hand] = true
+			if h.Gesture != "" {
+				m.publish("gesture/"+hand, string(h.Gesture), false)
+			}
+			if p, ok := m.patterns[hand]; !ok || p != h.Fingers {
+				m.patterns[hand] = h.Fingers
+				m.publish("pattern/"+hand, h.Fingers.String(), false)
+			}
+		}
+		for hand := range m.patterns {
+			if !seen[hand] {
+				delete(m.patterns, hand)
+			}
+		}
+	case consumer.RecordTypeMode:
+		m.publish("mode", string(r.Mode), true)
+	case consumer.RecordTypeAction:
+		payload, err := json.Marshal(r.Action)
+		if err != nil {
+			m.logger.Warn("failed to encode action", slog.Any("error", err))
+			return
+		}
+		topic := "binding"
+		if r.Action.Binding != "" {
+			topic += "/" + r.Action.Binding
+		}
+		m.publish(topic, string(payload), false)
+		// Bindings without a device trigger, e.g. fallback and unnamed count
+		// bindings, are only published to the binding topic.
+		if r.Action.Trigger != "" {
+			m.publish("trigger/"+r.Action.Trigger, string(payload), false)
+		}
+	}
+}
+
+// publish publishes to the given topic relative to the topic prefix.
+func (m *Client) publish(topic, payload string, retain bool) {
+	m.publishTopic(m.topic(topic), payload, retain)
+}
+
+func (m *Client) publishTopic(topic, payload string, retain bool) {
+	if !m.client.IsConnected() {
+		return
+	}
+	token := m.client.Publish(topic, byte(m.cfg.QoS), retain, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		m.logger.Debug("MQTT publish timeout", slog.String("topic", topic))
+		return
+	}
+	if err := token.Error(); err != nil {
+		m.logger.Debug("failed to publish MQTT message", slog.String("topic", topic), slog.Any("error", err))
+	}
+}
+
+func (m *Client) topic(name string) string {
+	return m.cfg.TopicPrefix + "/" + name
+}
+
+// command is received on the command topic to trigger a binding
+// or to switch between the active, paused, armed and disarmed modes.
+type command struct {
+	Command string        `json:"command"`
+	Binding string        `json:"binding,omitempty"`
+	Mode    consumer.Mode `json:"mode,omitempty"`
+}
+
+func (m *Client) handleCommand(_ paho.Client, msg paho.Message) {
+	var cmd command
+	if err := json.Unmarshal(msg.Payload(), &cmd); err != nil {
+		m.logger.Warn("invalid MQTT command", slog.String("payload", string(msg.Payload())), slog.Any("error", err))
+		return
+	}
+
+	var err error
+	switch cmd.Command {
+	case "trigger":
+		err = m.consumer.Trigger(cmd.Binding)
+	case "mode":
+		err = m.consumer.SetMode(cmd.Mode)
+	default:
+		err = fmt.Errorf("unknown command %q", cmd.Command)
+	}
+	if err != nil {
+		m.logger.Warn("failed to run MQTT command", slog.String("command", cmd.Command), slog.Any("error", err))
+		return
+	}
+	m.logger.Info("Ran MQTT command", slog.String("command", cmd.Command))
+}
+
+func tlsConfig(cfg *config.MQTTTLS) (*tls.Config, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mqtt ca_file: %w", err)
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("mqtt ca_file contains no certificates")
+		}
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mqtt client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
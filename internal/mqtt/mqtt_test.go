@@ -0,0 +1,287 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockConsumer struct {
+	mu        sync.Mutex
+	triggered []string
+	modes     []consumer.Mode
+}
+
+func (m *mockConsumer) Status() consumer.Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mode := consumer.ModeActive
+	if len(m.modes) > 0 {
+		mode = m.modes[len(m.modes)-1]
+	}
+	return consumer.Status{Mode: mode}
+}
+
+func (m *mockConsumer) Trigger(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name == "" {
+		return consumer.ErrBindingNotFound
+	}
+	m.triggered = append(m.triggered, name)
+	return nil
+}
+
+func (m *mockConsumer) SetMode(mode consumer.Mode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.modes = append(m.modes, mode)
+	return nil
+}
+
+func (m *mockConsumer) calls() ([]string, []consumer.Mode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.triggered...), append([]consumer.Mode(nil), m.modes...)
+}
+
+type message struct {
+	topic   string
+	payload string
+}
+
+// broker is an in-process MQTT broker recording the messages it receives.
+type broker struct {
+	t        *testing.T
+	addr     string
+	server   *mochi.Server
+	mu       sync.Mutex
+	messages []message
+}
+
+func newBroker(t *testing.T, addr string) *broker {
+	t.Helper()
+	if addr == "" {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr = l.Addr().String()
+		l.Close()
+	}
+
+	b := &broker{t: t, addr: addr}
+	b.server = mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, b.server.AddHook(new(auth.AllowHook), nil))
+	require.NoError(t, b.server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})))
+	record := func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.messages = append(b.messages, message{topic: pk.TopicName, payload: string(pk.Payload)})
+	}
+	require.NoError(t, b.server.Subscribe("lifx-force/#", 1, record))
+	require.NoError(t, b.server.Subscribe("homeassistant/#", 2, record))
+	go b.server.Serve()
+	return b
+}
+
+func (b *broker) received() []message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]message(nil), b.messages...)
+}
+
+// waitFor waits until the broker has received the given message.
+func (b *broker) waitFor(want message) {
+	b.t.Helper()
+	assert.Eventually(b.t, func() bool {
+		for _, m := range b.received() {
+			if m == want {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "message %v not received", want)
+}
+
+func testConfig(addr string) *config.Config {
+	return &config.Config{MQTT: config.MQTT{
+		Broker:       "tcp://" + addr,
+		ClientID:     "lifx-force-test",
+		TopicPrefix:  "lifx-force",
+		MinBackoffMs: 10,
+		MaxBackoffMs: 100,
+		Discovery:    config.Discovery{Prefix: "homeassistant"},
+	}}
+}
+
+func newTestClient(t *testing.T, cfg *config.Config, c Consumer) (*Client, *stream.Hub, context.CancelFunc) {
+	t.Helper()
+	log := logger.NewLogger(slog.LevelInfo, "")
+	hub := stream.NewHub(stream.DefaultBufferSize, log)
+	client, err := New(cfg, c, hub, log)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	return client, hub, func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestClientPublish(t *testing.T) {
+	var (
+		b       = newBroker(t, "")
+		peace   = config.FingerPattern{0, 1, 1, 0, 0}
+		now     = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		event   = consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{{Label: consumer.LeftHandLabel, Fingers: peace, Gesture: config.GestureSwipeUp}}}}
+		named   = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Binding: "movie_mode", Trigger: "movie_mode", Serials: []string{"d073d5000000"}}}
+		unnamed = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Presence: config.PresenceLeave, Serials: []string{}}}
+		// count and fallback are actions of unnamed count and fallback bindings, which have no trigger.
+		paused   = consumer.Record{Type: consumer.RecordTypeMode, Time: now, Mode: consumer.ModePaused}
+		count    = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Hand: "left", Fingers: &peace, Serials: []string{}}}
+		fallback = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Gesture: config.GestureSwipeUp, Hand: "left", Serials: []string{}}}
+	)
+	defer b.server.Close()
+
+	client, hub, stop := newTestClient(t, testConfig(b.addr), &mockConsumer{})
+	b.waitFor(message{"lifx-force/status", "online"})
+	// The current mode is published on connection and then when it changes.
+	b.waitFor(message{"lifx-force/mode", "active"})
+	require.Eventually(t, client.client.IsConnected, time.Second, 10*time.Millisecond)
+
+	hub.Record(event)
+	hub.Record(event)
+	hub.Record(paused)
+	hub.Record(named)
+	hub.Record(count)
+	hub.Record(fallback)
+	hub.Record(unnamed)
+
+	b.waitFor(message{"lifx-force/binding", `{"hand":"left","fingers":[0,1,1,0,0],"serials":[]}`})
+	b.waitFor(message{"lifx-force/binding", `{"gesture":"swipe_up","hand":"left","serials":[]}`})
+	b.waitFor(message{"lifx-force/binding", `{"presence":"leave","serials":[]}`})
+	b.waitFor(message{"lifx-force/mode", "paused"})
+	b.waitFor(message{"lifx-force/binding/movie_mode", `{"binding":"movie_mode","trigger":"movie_mode","serials":["d073d5000000"]}`})
+	stop()
+	b.waitFor(message{"lifx-force/status", "offline"})
+
+	var gestures, patterns, triggers int
+	for _, m := range b.received() {
+		if strings.HasPrefix(m.topic, "lifx-force/trigger/") {
+			triggers++
+		}
+		switch m {
+		case message{"lifx-force/gesture/left", "swipe_up"}:
+			gestures++
+		case message{"lifx-force/pattern/left", "01100"}:
+			patterns++
+		}
+	}
+	assert.Equal(t, 2, gestures)
+	// Patterns are only published when they change.
+	assert.Equal(t, 1, patterns)
+	// Only the named binding is published to a trigger topic.
+	assert.Equal(t, 1, triggers)
+}
+
+func TestClientCommands(t *testing.T) {
+	b := newBroker(t, "")
+	defer b.server.Close()
+
+	c := &mockConsumer{}
+	client, _, stop := newTestClient(t, testConfig(b.addr), c)
+	defer stop()
+	b.waitFor(message{"lifx-force/status", "online"})
+	require.Eventually(t, client.client.IsConnected, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, b.server.Publish("lifx-force/command", []byte(`{"command":"trigger","binding":"movie_mode"}`), false, 0))
+	require.NoError(t, b.server.Publish("lifx-force/command", []byte(`{"command":"trigger"}`), false, 0))
+	require.NoError(t, b.server.Publish("lifx-force/command", []byte(`not json`), false, 0))
+	require.NoError(t, b.server.Publish("lifx-force/command", []byte(`{"command":"mode","mode":"armed"}`), false, 0))
+	require.NoError(t, b.server.Publish("lifx-force/command", []byte(`{"command":"mode","mode":"paused"}`), false, 0))
+
+	assert.Eventually(t, func() bool {
+		triggered, modes := c.calls()
+		// Commands are not handled in order.
+		slices.Sort(modes)
+		return assert.ObjectsAreEqual([]string{"movie_mode"}, triggered) &&
+			assert.ObjectsAreEqual([]consumer.Mode{consumer.ModeArmed, consumer.ModePaused}, modes)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestClientReconnect(t *testing.T) {
+	b := newBroker(t, "")
+	addr := b.addr
+
+	client, hub, stop := newTestClient(t, testConfig(addr), &mockConsumer{})
+	defer stop()
+	b.waitFor(message{"lifx-force/status", "online"})
+	require.Eventually(t, client.client.IsConnected, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, b.server.Close())
+	require.Eventually(t, func() bool { return !client.client.IsConnected() }, 5*time.Second, 10*time.Millisecond)
+
+	// The client reconnects once the broker is back.
+	b = newBroker(t, addr)
+	defer b.server.Close()
+	b.waitFor(message{"lifx-force/status", "online"})
+
+	hub.Record(consumer.Record{Type: consumer.RecordTypeAction, Action: &consumer.ActionRecord{Binding: "off", Serials: []string{}}})
+	b.waitFor(message{"lifx-force/binding/off", `{"binding":"off","serials":[]}`})
+}
+
+func TestTLSConfig(t *testing.T) {
+	testCases := map[string]struct {
+		cfg     *config.MQTTTLS
+		wantErr error
+	}{
+		"system roots": {
+			cfg: &config.MQTTTLS{InsecureSkipVerify: true},
+		},
+		"missing ca file": {
+			cfg:     &config.MQTTTLS{CAFile: "missing.pem"},
+			wantErr: errors.New("failed to read mqtt ca_file"),
+		},
+		"missing client certificate": {
+			cfg:     &config.MQTTTLS{CertFile: "missing.pem", KeyFile: "missing.key"},
+			wantErr: errors.New("failed to load mqtt client certificate"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tlsConfig(tc.cfg)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.cfg.InsecureSkipVerify, got.InsecureSkipVerify)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package osc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+)
+
+const (
+	gestureAddress = "/lifx-force/gesture"
+	fingersAddress = "/lifx-force/fingers"
+)
+
+// Sender sends the hands of every event as OSC messages over UDP:
+//   - /lifx-force/gesture <hand> <gesture>, when a gesture is detected
+//   - /lifx-force/fingers <hand> <f0> <f1> <f2> <f3> <f4>
+type Sender struct {
+	conn   net.Conn
+	hub    *stream.Hub
+	logger *slog.Logger
+}
+
+func New(cfg *config.Config, hub *stream.Hub, logger *slog.Logger) (*Sender, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(cfg.OSC.Host, strconv.Itoa(cfg.OSC.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve osc target: %w", err)
+	}
+	return &Sender{conn: conn, hub: hub, logger: logger}, nil
+}
+
+// Run sends the events of the hub until the context is cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	defer s.conn.Close()
+	sub := s.hub.Subscribe(stream.Filter{Types: []consumer.RecordType{consumer.RecordTypeEvent}})
+	defer s.hub.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			s.send(r.Event)
+		}
+	}
+}
+
+func (s *Sender) send(e *consumer.Event) {
+	for _, h := range e.Hands {
+		hand := string(h.Label)
+		if h.Gesture != "" {
+			s.write(message(gestureAddress, hand, string(h.Gesture)))
+		}
+		args := []any{hand}
+		for _, f := range h.Fingers {
+			args = append(args, int32(f))
+		}
+		s.write(message(fingersAddress, args...))
+	}
+}
+
+func (s *Sender) write(msg []byte) {
+	// UDP writes do not block on the receiver, errors are only
+	// reported when the target is unreachable.
+	if _, err := s.conn.Write(msg); err != nil {
+		s.logger.Debug("failed to send OSC message", slog.Any("error", err))
+	}
+}
+
+// message encodes an OSC message with string and int32 arguments.
+func message(address string, args ...any) []byte {
+	tags := ","
+	var data []byte
+	for _, a := range args {
+		switch v := a.(type) {
+		case string:
+			tags += "s"
+			data = appendString(data, v)
+		case int32:
+			tags += "i"
+			data = binary.BigEndian.AppendUint32(data, uint32(v))
+		}
+	}
+
+	msg := appendString(nil, address)
+	msg = appendString(msg, tags)
+	return append(msg, data...)
+}
+
+// appendString appends a null terminated string padded to a multiple of 4 bytes.
+func appendString(b []byte, s string) []byte {
+	b = append(b, s...)
+	pad := 4 - len(s)%4
+	for range pad {
+		b = append(b, 0)
+	}
+	return b
+}
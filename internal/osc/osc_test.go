@@ -0,0 +1,88 @@
+package osc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/alessio-palumbo/lifx-force/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	testCases := map[string]struct {
+		address string
+		args    []any
+		want    []byte
+	}{
+		"strings": {
+			address: "/lifx-force/gesture",
+			args:    []any{"left", "swipe_up"},
+			want: []byte("/lifx-force/gesture\x00" +
+				",ss\x00" +
+				"left\x00\x00\x00\x00" +
+				"swipe_up\x00\x00\x00\x00"),
+		},
+		"string and ints": {
+			address: "/lifx-force/fingers",
+			args:    []any{"right", int32(0), int32(1)},
+			want: []byte("/lifx-force/fingers\x00" +
+				",sii\x00\x00\x00\x00" +
+				"right\x00\x00\x00" +
+				"\x00\x00\x00\x00" +
+				"\x00\x00\x00\x01"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := message(tc.address, tc.args...)
+			assert.Equal(t, tc.want, got)
+			assert.Zero(t, len(got)%4)
+		})
+	}
+}
+
+func TestSender(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	port := l.LocalAddr().(*net.UDPAddr).Port
+
+	cfg := &config.Config{OSC: config.OSC{Host: "127.0.0.1", Port: port}}
+	log := logger.NewLogger(slog.LevelInfo, "")
+	hub := stream.NewHub(stream.DefaultBufferSize, log)
+	s, err := New(cfg, hub, log)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	hub.Record(consumer.Record{Type: consumer.RecordTypeAction, Action: &consumer.ActionRecord{}})
+	hub.Record(consumer.Record{Type: consumer.RecordTypeEvent, Event: &consumer.Event{Hands: []consumer.Hand{
+		{Label: consumer.LeftHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}, Gesture: config.GestureSwipeUp},
+		{Label: consumer.RightHandLabel, Fingers: config.FingerPattern{1, 1, 1, 1, 1}},
+	}}})
+
+	want := [][]byte{
+		message(gestureAddress, "left", "swipe_up"),
+		message(fingersAddress, "left", int32(0), int32(1), int32(1), int32(0), int32(0)),
+		message(fingersAddress, "right", int32(1), int32(1), int32(1), int32(1), int32(1)),
+	}
+	buf := make([]byte, 1024)
+	for i, w := range want {
+		require.NoError(t, l.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := l.ReadFrom(buf)
+		require.NoError(t, err, "message "+strconv.Itoa(i))
+		assert.Equal(t, w, buf[:n])
+	}
+}
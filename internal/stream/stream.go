@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+)
+
+const (
+	// DefaultBufferSize is the number of records buffered for each subscriber.
+	DefaultBufferSize = 64
+	// recentActions is the number of action records kept by the hub.
+	recentActions = 50
+)
+
+// Filter selects the records delivered to a subscriber.
+// Empty fields match all records.
+type Filter struct {
+	Types []consumer.RecordType
+	Hand  string
+}
+
+// Subscription delivers the records published to the hub matching its filter.
+type Subscription struct {
+	C       <-chan consumer.Record
+	c       chan consumer.Record
+	filter  Filter
+	dropped atomic.Int64
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Hub fans out the records of the consumer to its subscribers.
+// Each subscriber has its own buffer, records are dropped for
+// subscribers whose buffer is full so that publishing never blocks.
+type Hub struct {
+	logger     *slog.Logger
+	bufferSize int
+	mu         sync.Mutex
+	subs       map[*Subscription]struct{}
+	recent     []consumer.Record
+}
+
+func NewHub(bufferSize int, logger *slog.Logger) *Hub {
+	return &Hub{
+		logger:     logger,
+		bufferSize: max(bufferSize, 1),
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Record publishes a record to the subscribers, implementing consumer.Recorder.
+func (h *Hub) Record(r consumer.Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r.Type == consumer.RecordTypeAction {
+		h.recent = append(h.recent, r)
+		if len(h.recent) > recentActions {
+			h.recent = h.recent[len(h.recent)-recentActions:]
+		}
+	}
+	for s := range h.subs {
+		r, ok := s.filter.apply(r)
+		if !ok {
+			continue
+		}
+		select {
+		case s.c <- r:
+		default:
+			s.dropped.Add(1)
+			h.logger.Debug("stream subscriber buffer full, dropping record", slog.Any("type", r.Type))
+		}
+	}
+}
+
+// Subscribe registers a subscriber receiving the records matching the filter.
+func (h *Hub) Subscribe(f Filter) *Subscription {
+	c := make(chan consumer.Record, h.bufferSize)
+	s := &Subscription{C: c, c: c, filter: f}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Unsubscribe removes the subscriber and closes its channel.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
+
+// RecentActions returns the latest action records, oldest first.
+func (h *Hub) RecentActions() []consumer.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.recent)
+}
+
+// Subscribers returns the number of registered subscribers.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// apply returns the record restricted to the filter and whether it matches.
+// Events are reduced to the hands matching the filter.
+func (f Filter) apply(r consumer.Record) (consumer.Record, bool) {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, r.Type) {
+		return r, false
+	}
+	if f.Hand == "" {
+		return r, true
+	}
+
+	switch {
+	case r.Event != nil:
+		var hands []consumer.Hand
+		for _, h := range r.Event.Hands {
+			if string(h.Label) == f.Hand {
+				hands = append(hands, h)
+			}
+		}
+		if len(hands) == 0 {
+			return r, false
+		}
+		r.Event = &consumer.Event{Hands: hands}
+	case r.Action != nil:
+		if r.Action.Hand != f.Hand {
+			return r, false
+		}
+	}
+	return r, true
+}
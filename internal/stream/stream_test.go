@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alessio-palumbo/lifx-force/internal/config"
+	"github.com/alessio-palumbo/lifx-force/internal/consumer"
+	"github.com/alessio-palumbo/lifx-force/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	var (
+		now   = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		left  = consumer.Hand{Label: consumer.LeftHandLabel, Fingers: config.FingerPattern{0, 1, 1, 0, 0}}
+		right = consumer.Hand{Label: consumer.RightHandLabel, Gesture: config.GestureSwipeUp}
+		event = consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{left, right}}}
+		act   = consumer.Record{Type: consumer.RecordTypeAction, Time: now, Action: &consumer.ActionRecord{Hand: "right", Gesture: config.GestureSwipeUp}}
+	)
+
+	testCases := map[string]struct {
+		filter    Filter
+		record    consumer.Record
+		want      consumer.Record
+		wantMatch bool
+	}{
+		"empty filter matches event": {
+			record:    event,
+			want:      event,
+			wantMatch: true,
+		},
+		"type matches": {
+			filter:    Filter{Types: []consumer.RecordType{consumer.RecordTypeAction}},
+			record:    act,
+			want:      act,
+			wantMatch: true,
+		},
+		"type does not match": {
+			filter: Filter{Types: []consumer.RecordType{consumer.RecordTypeAction}},
+			record: event,
+		},
+		"hand reduces event": {
+			filter:    Filter{Hand: consumer.LeftHandLabel},
+			record:    event,
+			want:      consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{left}}},
+			wantMatch: true,
+		},
+		"hand missing from event": {
+			filter: Filter{Hand: consumer.LeftHandLabel},
+			record: consumer.Record{Type: consumer.RecordTypeEvent, Time: now, Event: &consumer.Event{Hands: []consumer.Hand{right}}},
+		},
+		"hand matches action": {
+			filter:    Filter{Hand: consumer.RightHandLabel},
+			record:    act,
+			want:      act,
+			wantMatch: true,
+		},
+		"hand does not match action": {
+			filter: Filter{Hand: consumer.LeftHandLabel},
+			record: act,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tc.filter.apply(tc.record)
+			assert.Equal(t, tc.wantMatch, ok)
+			if ok {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHub(t *testing.T) {
+	var (
+		event  = consumer.Record{Type: consumer.RecordTypeEvent, Event: &consumer.Event{}}
+		action = consumer.Record{Type: consumer.RecordTypeAction, Action: &consumer.ActionRecord{}}
+	)
+
+	t.Run("fans out to subscribers", func(t *testing.T) {
+		h := NewHub(DefaultBufferSize, logger.NewLogger(slog.LevelInfo, ""))
+		all := h.Subscribe(Filter{})
+		actions := h.Subscribe(Filter{Types: []consumer.RecordType{consumer.RecordTypeAction}})
+
+		h.Record(event)
+		h.Record(action)
+
+		assert.Equal(t, event, <-all.C)
+		assert.Equal(t, action, <-all.C)
+		assert.Equal(t, action, <-actions.C)
+		assert.Empty(t, actions.C)
+	})
+
+	t.Run("slow subscriber does not block", func(t *testing.T) {
+		h := NewHub(2, logger.NewLogger(slog.LevelInfo, ""))
+		slow := h.Subscribe(Filter{})
+		fast := h.Subscribe(Filter{})
+
+		for range 5 {
+			h.Record(event)
+			<-fast.C
+		}
+		assert.Len(t, slow.C, 2)
+		assert.Equal(t, int64(3), slow.Dropped())
+		assert.Equal(t, int64(0), fast.Dropped())
+	})
+
+	t.Run("keeps recent actions", func(t *testing.T) {
+		h := NewHub(DefaultBufferSize, logger.NewLogger(slog.LevelInfo, ""))
+		assert.Empty(t, h.RecentActions())
+
+		for i := range recentActions + 5 {
+			h.Record(event)
+			h.Record(consumer.Record{Type: consumer.RecordTypeAction, Action: &consumer.ActionRecord{Binding: fmt.Sprint(i)}})
+		}
+		recent := h.RecentActions()
+		assert.Len(t, recent, recentActions)
+		assert.Equal(t, "5", recent[0].Action.Binding)
+		assert.Equal(t, fmt.Sprint(recentActions+4), recent[len(recent)-1].Action.Binding)
+	})
+
+	t.Run("unsubscribe closes channel", func(t *testing.T) {
+		h := NewHub(DefaultBufferSize, logger.NewLogger(slog.LevelInfo, ""))
+		s := h.Subscribe(Filter{})
+		h.Unsubscribe(s)
+		h.Unsubscribe(s)
+		h.Record(event)
+
+		_, ok := <-s.C
+		assert.False(t, ok)
+	})
+}